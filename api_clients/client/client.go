@@ -1,14 +1,36 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"time"
+	"user-service/pkg/logger"
+	"user-service/pkg/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const baseAgeURL = "https://api.agify.io"
 const baseGenderURL = "https://api.genderize.io"
 const baseNationURL = "https://api.nationalize.io"
 
+// tracerScope - имя библиотеки инструментирования запросов обогащения в спанах
+const tracerScope = "user-service/api_clients/client"
+
+// Имена сервисов обогащения в метриках и спанах
+const (
+	providerAgify       = "agify"
+	providerGenderize   = "genderize"
+	providerNationalize = "nationalize"
+)
+
 type AgeResponse struct {
 	Count int    `json:"count"`
 	Name  string `json:"name"`
@@ -32,38 +54,65 @@ type NationResponse struct {
 	Country []CountryProbability `json:"country"`
 }
 
-func GetAgeByName(name string) (AgeResponse, error) {
-	resp, err := http.Get(baseAgeURL + "?name=" + name)
-	if err != nil {
-		return AgeResponse{}, err
-	}
-	defer resp.Body.Close()
-
+func GetAgeByName(ctx context.Context, name string) (AgeResponse, error) {
 	var r AgeResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	err := get(ctx, providerAgify, nameURL(baseAgeURL, name), &r)
 	return r, err
 }
 
-func GetGenderByName(name string) (GenderResponse, error) {
-	resp, err := http.Get(baseGenderURL + "?name=" + name)
-	if err != nil {
-		return GenderResponse{}, err
-	}
-	defer resp.Body.Close()
-
+func GetGenderByName(ctx context.Context, name string) (GenderResponse, error) {
 	var r GenderResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	err := get(ctx, providerGenderize, nameURL(baseGenderURL, name), &r)
 	return r, err
 }
 
-func GetNationalityByName(name string) (NationResponse, error) {
-	resp, err := http.Get(baseNationURL + "?name=" + name)
-	if err != nil {
-		return NationResponse{}, err
-	}
-	defer resp.Body.Close()
-
+func GetNationalityByName(ctx context.Context, name string) (NationResponse, error) {
 	var r NationResponse
-	err = json.NewDecoder(resp.Body).Decode(&r)
+	err := get(ctx, providerNationalize, nameURL(baseNationURL, name), &r)
 	return r, err
 }
+
+// nameURL добавляет имя к адресу сервиса обогащения параметром name. Имя экранируется:
+// в нем бывают пробелы (двойные имена), & и символы транслитерации.
+func nameURL(base, name string) string {
+	return base + "?" + url.Values{"name": {name}}.Encode()
+}
+
+// get запрашивает сервис обогащения и декодирует ответ в r, ответ не 2xx считается ошибкой. Длительность и ошибки пишутся в метрики,
+// на запрос создается спан. Контекст трассировки сторонним сервисам не передается, только X-Request-ID,
+// а URL в спан не пишется: в нем имя пользователя.
+func get(ctx context.Context, provider, rawURL string, r interface{}) error {
+	ctx, span := otel.Tracer(tracerScope).Start(ctx, "enrichment "+provider, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("enrichment.provider", provider), semconv.HTTPMethod(http.MethodGet)))
+	defer span.End()
+
+	start := time.Now()
+	err := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return err
+		}
+		if id := logger.RequestID(ctx); id != "" {
+			req.Header.Set(logger.HeaderRequestID, id)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+		// тело ответа с ошибкой (лимит запросов, сбой сервиса) декодировалось бы в нулевые значения
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s responded with status %d", provider, resp.StatusCode)
+		}
+
+		return json.NewDecoder(resp.Body).Decode(r)
+	}()
+	metrics.EnrichmentDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.EnrichmentErrors.WithLabelValues(provider).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
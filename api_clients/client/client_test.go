@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNameURL(t *testing.T) {
+	for _, name := range []string{"Anna Mariia", "a&b=c", "Ob`edkov"} {
+		u, err := url.Parse(nameURL(baseAgeURL, name))
+		if err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if got := u.Query().Get("name"); got != name {
+			t.Errorf("got name %q, wanted %q", got, name)
+		}
+		if u.Host != "api.agify.io" || len(u.Query()) != 1 {
+			t.Errorf("unexpected url %s", u)
+		}
+	}
+}
+
+func TestGetFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") == "limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":"Request limit reached"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"count":1,"name":"ivan","age":42}`))
+	}))
+	defer server.Close()
+
+	var r AgeResponse
+	if err := get(context.Background(), providerAgify, nameURL(server.URL, "ivan"), &r); err != nil || r.Age != 42 {
+		t.Fatalf("got %+v, %v, wanted age 42", r, err)
+	}
+
+	r = AgeResponse{}
+	if err := get(context.Background(), providerAgify, nameURL(server.URL, "limited"), &r); err == nil {
+		t.Errorf("expected error for status 429, got %+v", r)
+	}
+}
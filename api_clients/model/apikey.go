@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// APIKey - ключ доступа к API для сервисов, которые не умеют OAuth. Сам ключ хранится только в виде хэша.
+type APIKey struct {
+	ID         int64      `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Owner      string     `json:"owner" db:"owner"`
+	Roles      []string   `json:"roles" db:"roles"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	UsageCount int64      `json:"usage_count" db:"usage_count"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// IssuedAPIKey - ключ вместе с секретом, который показывается только при выпуске и ротации
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyUsage - число запросов по ключу с последнего сохранения и время последнего из них
+type APIKeyUsage struct {
+	ID         int64
+	Count      int64
+	LastUsedAt time.Time
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditRecord - запись журнала изменений пользователя
+type AuditRecord struct {
+	ID        int64                  `json:"id" db:"id"`
+	UserID    int                    `json:"user_id" db:"user_id"`
+	Actor     string                 `json:"actor" db:"actor"`
+	Source    string                 `json:"source" db:"source"`
+	Operation string                 `json:"operation" db:"operation"`
+	Before    json.RawMessage        `json:"before,omitempty" db:"before"`
+	After     json.RawMessage        `json:"after,omitempty" db:"after"`
+	Details   json.RawMessage        `json:"details,omitempty" db:"details"`
+	Changes   map[string]FieldChange `json:"changes,omitempty" db:"-"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
+
+// FieldChange - старое и новое значение одного поля
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AccessDenial - отказ в доступе, который записывается в журнал аудита
+type AccessDenial struct {
+	Subject    string `json:"subject"`
+	Permission string `json:"permission"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	UserID     *int   `json:"user_id,omitempty"`
+}
@@ -0,0 +1,25 @@
+package model
+
+// Операции пакетной обработки пользователей
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation - одна операция пакетного запроса: для create заполняется User,
+// для update - ID и User, для delete - только ID
+type BatchOperation struct {
+	Op   string `json:"op"`
+	ID   int    `json:"id,omitempty"`
+	User User   `json:"user"`
+}
+
+// BatchResult - результат выполнения одной операции пакетного запроса
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     int    `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,35 @@
+package model
+
+// DuplicateCandidate - пользователь, похожий на проверяемого, Score - сходство ФИО от 0 до 1
+type DuplicateCandidate struct {
+	User  User    `json:"user"`
+	Score float64 `json:"score"`
+}
+
+// MergeRequest - id дубликатов, которые сливаются в сохраняемого пользователя
+type MergeRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// UserMerged - событие слияния: дубликаты MergedIDs удалены, их поля и история перенесены в User
+type UserMerged struct {
+	SurvivorID int   `json:"survivor_id"`
+	MergedIDs  []int `json:"merged_ids"`
+	User       User  `json:"user"`
+}
+
+// MergeFrom заполняет пустые поля пользователя значениями из other, заполненные поля не меняются
+func (u *User) MergeFrom(other User) {
+	if u.Patronymic == "" {
+		u.Patronymic = other.Patronymic
+	}
+	if u.Age == 0 {
+		u.Age = other.Age
+	}
+	if u.Gender == "" {
+		u.Gender = other.Gender
+	}
+	if u.Nationality == "" {
+		u.Nationality = other.Nationality
+	}
+}
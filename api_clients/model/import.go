@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Статусы задачи импорта
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// Import - задача импорта ФИО из файла
+type Import struct {
+	ID            int64            `json:"id" db:"id"`
+	Filename      string           `json:"filename" db:"filename"`
+	Format        string           `json:"format" db:"format"`
+	Mode          string           `json:"mode" db:"mode"`
+	Status        string           `json:"status" db:"status"`
+	ProcessedRows int              `json:"processed_rows" db:"processed_rows"`
+	FailedRows    int              `json:"failed_rows" db:"failed_rows"`
+	Error         string           `json:"error,omitempty" db:"error"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	FinishedAt    *time.Time       `json:"finished_at,omitempty" db:"finished_at"`
+	Errors        []ImportRowError `json:"errors,omitempty" db:"-"`
+}
+
+// ImportRowError - ошибка обработки одной строки файла
+type ImportRowError struct {
+	Row   int    `json:"row" db:"row_number"`
+	Error string `json:"error" db:"error"`
+	Raw   string `json:"raw,omitempty" db:"raw"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий outbox
+const (
+	EventUserMerged = "user.merged"
+)
+
+// OutboxEvent - событие, записанное в той же транзакции, что и изменение, и опубликованное в Kafka позже
+type OutboxEvent struct {
+	ID          int64           `json:"id" db:"id"`
+	Aggregate   string          `json:"aggregate" db:"aggregate"`
+	AggregateID int             `json:"aggregate_id" db:"aggregate_id"`
+	Type        string          `json:"type" db:"event_type"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	// Headers - заголовки сообщения Kafka (traceparent, X-Request-ID) запроса, в котором записано событие
+	Headers map[string]string `json:"-" db:"headers"`
+}
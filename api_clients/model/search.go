@@ -0,0 +1,9 @@
+package model
+
+// SearchResult - найденный пользователь, Score - триграммное сходство с запросом от 0 до 1.
+// Highlight - поля ФИО с совпавшими фрагментами в <em>, остальной текст экранирован как HTML.
+type SearchResult struct {
+	User      User              `json:"user"`
+	Score     float64           `json:"score"`
+	Highlight map[string]string `json:"highlight,omitempty"`
+}
@@ -0,0 +1,27 @@
+package model
+
+// StatsUnknown - ключ для пользователей без пола или национальности в разбивках статистики
+const StatsUnknown = "unknown"
+
+// UserStats - агрегаты по пользователям, подходящим под фильтр списка
+type UserStats struct {
+	Total int `json:"total"`
+	// ByGender и ByNationality - число пользователей по значению поля
+	ByGender      map[string]int `json:"by_gender"`
+	ByNationality map[string]int `json:"by_nationality"`
+	// ByAge - группы по возрасту ширины BucketWidth по возрастанию, пользователи без возраста не учитываются
+	ByAge       []AgeBucket `json:"by_age"`
+	BucketWidth int         `json:"bucket_width"`
+	// MeanAge и MedianAge равны nil, если ни у кого не указан возраст
+	MeanAge   *float64 `json:"mean_age"`
+	MedianAge *float64 `json:"median_age"`
+	// GenderByNationality - таблица пол x национальность: GenderByNationality[gender][nationality]
+	GenderByNationality map[string]map[string]int `json:"gender_by_nationality"`
+}
+
+// AgeBucket - число пользователей с возрастом от From до To включительно
+type AgeBucket struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
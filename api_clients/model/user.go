@@ -1,11 +1,21 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type User struct {
-	ID          int    `json:"id" db:"id"`
-	Name        string `json:"name" db:"name"`
-	Surname     string `json:"surname" db:"surname"`
-	Patronymic  string `json:"patronymic" db:"patronymic"`
-	Age         int    `json:"age" db:"age"`
-	Gender      string `json:"gender" db:"gender"`
-	Nationality string `json:"nationality" db:"nationality"`
+	ID          int        `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Surname     string     `json:"surname" db:"surname"`
+	Patronymic  string     `json:"patronymic" db:"patronymic"`
+	Age         int        `json:"age" db:"age"`
+	Gender      string     `json:"gender" db:"gender"`
+	Nationality string     `json:"nationality" db:"nationality"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Latin - ФИО латиницей для поиска, заполняется, если включено хранение транслитерации
+	Latin string `json:"latin,omitempty" db:"latin"`
+	// RawInput - ФИО в том виде, в котором оно пришло, до нормализации
+	RawInput json.RawMessage `json:"raw_input,omitempty" db:"raw_input"`
 }
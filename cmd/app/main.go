@@ -1,7 +1,15 @@
 package main
 
-import "user-service/internal/app"
+import (
+	"os"
+	"user-service/internal/app"
+)
 
 func main() {
+	// подкоманда import разово импортирует файл вместо запуска сервиса
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		app.RunImport(os.Args[2:])
+		return
+	}
 	app.Run()
 }
@@ -16,7 +16,19 @@ type Config struct {
 	Kafka            `yaml:"kafka"`
 	HTTPServer       `yaml:"http_server"`
 	Redis            `yaml:"redis"`
+	Auth             `yaml:"auth"`
+	RBAC             `yaml:"rbac"`
+	RateLimit        `yaml:"rate_limit"`
 	Log              `yaml:"log"`
+	Retention        `yaml:"retention"`
+	Names            `yaml:"names"`
+	Duplicates       `yaml:"duplicates"`
+	Outbox           `yaml:"outbox"`
+	Stats            `yaml:"stats"`
+	Admin            Admin `yaml:"admin"`
+	Tracing          `yaml:"tracing"`
+	Health           `yaml:"health"`
+	AccessLog        `yaml:"access_log"`
 }
 
 type Postgres struct {
@@ -46,26 +58,149 @@ type HTTPServer struct {
 	Port        string        `yaml:"server_port" env:"PORT" env-required:"true"`
 }
 
+// Redis настраивает кэш. Backend: redis, memory (в памяти процесса) или none (без кэша).
+// Пока Redis не отвечает, используется Fallback: memory или none, Redis проверяется снова раз в RetryInterval.
 type Redis struct {
-	Addr     string `yaml:"address"`
-	Password string `env:"REDIS_PASS" env-required:"true"`
+	Backend       string        `yaml:"backend" env:"CACHE_BACKEND" env-default:"redis"`
+	Fallback      string        `yaml:"fallback" env:"CACHE_FALLBACK" env-default:"none"`
+	Addr          string        `yaml:"address"`
+	Password      string        `env:"REDIS_PASS"`
+	PingTimeout   time.Duration `yaml:"ping_timeout" env:"REDIS_PING_TIMEOUT" env-default:"2s"`
+	RetryInterval time.Duration `yaml:"retry_interval" env:"REDIS_RETRY_INTERVAL" env-default:"10s"`
+	StaleWindow   time.Duration `yaml:"stale_window" env:"REDIS_STALE_WINDOW" env-default:"30s"`
+	LockTTL       time.Duration `yaml:"lock_ttl" env:"REDIS_LOCK_TTL" env-default:"5s"`
+	LocalCache    LocalCache    `yaml:"local_cache"`
+}
+
+// LocalCache - локальный уровень кэша в памяти процесса перед Redis, его размеры
+// используются и для кэша backend: memory
+type LocalCache struct {
+	Enabled    bool          `yaml:"enabled" env:"LOCAL_CACHE_ENABLED" env-default:"false"`
+	MaxEntries int           `yaml:"max_entries" env:"LOCAL_CACHE_MAX_ENTRIES" env-default:"10000"`
+	MaxBytes   int64         `yaml:"max_bytes" env:"LOCAL_CACHE_MAX_BYTES" env-default:"67108864"`
+	TTL        time.Duration `yaml:"ttl" env:"LOCAL_CACHE_TTL" env-default:"5s"`
+	Channel    string        `yaml:"channel" env:"LOCAL_CACHE_CHANNEL" env-default:"cache:invalidate"`
 }
 
 type Log struct {
 	Level string `env-required:"true" yaml:"level" env:"LOG_LEVEL"`
 }
 
+// AccessLog настраивает журнал запросов. Output: stdout, file (Path с ротацией по MaxSize мегабайт,
+// хранением MaxAge дней и не более MaxBackups старых файлов, сжатием при Compress) или off.
+// Fields - поля строки журнала, Redact - параметры запроса, значения которых скрываются.
+type AccessLog struct {
+	Output     string   `yaml:"output" env:"ACCESS_LOG_OUTPUT" env-default:"stdout"`
+	Path       string   `yaml:"path" env:"ACCESS_LOG_PATH" env-default:"logs/requests.log"`
+	MaxSize    int      `yaml:"max_size" env:"ACCESS_LOG_MAX_SIZE" env-default:"100"`
+	MaxAge     int      `yaml:"max_age" env:"ACCESS_LOG_MAX_AGE" env-default:"7"`
+	MaxBackups int      `yaml:"max_backups" env:"ACCESS_LOG_MAX_BACKUPS" env-default:"10"`
+	Compress   bool     `yaml:"compress" env:"ACCESS_LOG_COMPRESS" env-default:"true"`
+	Fields     []string `yaml:"fields" env:"ACCESS_LOG_FIELDS" env-default:"time,method,uri,status,latency,request_id,error"`
+	Redact     []string `yaml:"redact" env:"ACCESS_LOG_REDACT" env-default:"token,access_token,api_key,password,secret"`
+}
+
+// Auth настраивает аутентификацию по JWT и API-ключам. Токены HS256 проверяются секретом или ключами kty=oct из JWKS,
+// RS256 - ключами kty=RSA из JWKS. Без токена доступны только PublicPaths.
+type Auth struct {
+	Enabled     bool     `yaml:"enabled" env:"AUTH_ENABLED" env-default:"true"`
+	Secret      string   `env:"JWT_SECRET"`
+	JWKSFile    string   `yaml:"jwks_file" env:"JWT_JWKS_FILE"`
+	Issuer      string   `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience    string   `yaml:"audience" env:"JWT_AUDIENCE"`
+	PublicPaths []string `yaml:"public_paths" env:"AUTH_PUBLIC_PATHS" env-default:"/healthz,/readyz"`
+	// APIKeyUsageFlush - как часто счетчики использования API-ключей сохраняются в базу
+	APIKeyUsageFlush time.Duration `yaml:"api_key_usage_flush" env:"API_KEY_USAGE_FLUSH" env-default:"1m"`
+}
+
+// RBAC задает разрешения каждой роли. Роль клиента - scope или роль из JWT либо роль API-ключа.
+type RBAC struct {
+	Enabled bool                `yaml:"enabled" env:"RBAC_ENABLED" env-default:"true"`
+	Roles   map[string][]string `yaml:"roles"`
+}
+
+// RateLimit ограничивает частоту запросов клиента. Backend: redis (общий лимит для всех экземпляров,
+// при недоступности Redis - в памяти) или memory. Ключ Routes - "METHOD /path", как путь зарегистрирован.
+type RateLimit struct {
+	Enabled bool                  `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	Backend string                `yaml:"backend" env:"RATE_LIMIT_BACKEND" env-default:"redis"`
+	Default RouteLimit            `yaml:"default"`
+	Routes  map[string]RouteLimit `yaml:"routes"`
+}
+
+// RouteLimit - не больше Rate запросов за Period, из них до Burst подряд (по умолчанию Burst = Rate)
+type RouteLimit struct {
+	Rate   int           `yaml:"rate" env-default:"100"`
+	Period time.Duration `yaml:"period" env-default:"1s"`
+	Burst  int           `yaml:"burst"`
+}
+
+// Retention задает срок хранения мягко удаленных пользователей и период запуска очистки
+type Retention struct {
+	Period   time.Duration `yaml:"period" env:"RETENTION_PERIOD" env-default:"720h"`
+	Interval time.Duration `yaml:"interval" env:"RETENTION_INTERVAL" env-default:"1h"`
+}
+
+// Names настраивает нормализацию ФИО. Language - тег языка BCP 47 для правил регистра (tr, nl), und - без языка.
+// Transliteration - схема латиницы для запросов обогащения: icao, gost или none. При StoreLatin ФИО латиницей
+// по той же схеме сохраняется в колонку latin и участвует в поиске.
+type Names struct {
+	Language        string `yaml:"language" env:"NAMES_LANGUAGE" env-default:"und"`
+	Transliteration string `yaml:"transliteration" env:"NAMES_TRANSLITERATION" env-default:"icao"`
+	StoreLatin      bool   `yaml:"store_latin" env:"NAMES_STORE_LATIN" env-default:"false"`
+}
+
+// Duplicates настраивает поиск дубликатов: кандидат попадает в ответ при сходстве ФИО не ниже Threshold,
+// для сравнения из базы берется не больше CandidateLimit пользователей с той же первой буквой фамилии
+type Duplicates struct {
+	Threshold      float64 `yaml:"threshold" env:"DUPLICATES_THRESHOLD" env-default:"0.85"`
+	CandidateLimit int     `yaml:"candidate_limit" env:"DUPLICATES_CANDIDATE_LIMIT" env-default:"1000"`
+}
+
+// Outbox настраивает публикацию событий из таблицы outbox в топик Kafka
+type Outbox struct {
+	Topic         string        `yaml:"topic" env:"OUTBOX_TOPIC" env-default:"user-events"`
+	RelayInterval time.Duration `yaml:"relay_interval" env:"OUTBOX_RELAY_INTERVAL" env-default:"1s"`
+	BatchSize     int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+}
+
+// Stats настраивает статистику по пользователям: AgeBucket - ширина возрастной группы в годах
+type Stats struct {
+	AgeBucket int `yaml:"age_bucket" env:"STATS_AGE_BUCKET" env-default:"10"`
+}
+
+// Admin - служебный HTTP-сервер с метриками Prometheus (/metrics), отдельный от API
+type Admin struct {
+	Port string `yaml:"port" env:"ADMIN_PORT" env-default:"9090"`
+}
+
+// Tracing настраивает трассировку OpenTelemetry. Exporter: otlp (OTLP/gRPC на Endpoint), stdout (для локального
+// запуска) или none. SampleRatio - доля записываемых трасс, если решение не принято отправителем.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" env-default:"http://localhost:4317"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" env-default:"user-service"`
+}
+
+// Health настраивает пробы /healthz и /readyz. Timeout ограничивает каждую проверку зависимости,
+// ShutdownDelay - сколько /readyz отвечает 503 перед остановкой, чтобы балансировщик успел убрать экземпляр.
+type Health struct {
+	Timeout       time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" env-default:"2s"`
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"HEALTH_SHUTDOWN_DELAY" env-default:"5s"`
+}
+
 func LoadConfig() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
 		log.Fatal("CONFIG_PATH is not set")
 	}
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatal("config file does not exist: %s", configPath)
+		log.Fatalf("config file does not exist: %s", configPath)
 	}
 	var cfg Config
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		log.Fatal("cannot read config: %s", err)
+		log.Fatalf("cannot read config: %s", err)
 	}
 	cfg.ConnectionString = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.DBPort, cfg.User, cfg.Postgres.Password, cfg.DBName, cfg.SSLMode)
@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
+	"user-service/service"
+)
+
+// HeaderAPIKey - заголовок с API-ключом сервисного клиента
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeyAuthenticator проверяет API-ключ и возвращает данные клиента
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, secret string) (auth.Claims, error)
+}
+
+// APIKeyAuth аутентифицирует запрос с заголовком X-API-Key. Запрос без заголовка проходит дальше,
+// к проверке JWT, неизвестный, отозванный или просроченный ключ - 401.
+func APIKeyAuth(authenticator APIKeyAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			secret := c.Request().Header.Get(HeaderAPIKey)
+			if secret == "" {
+				return next(c)
+			}
+
+			claims, err := authenticator.AuthenticateAPIKey(c.Request().Context(), secret)
+			if errors.Is(err, service.ErrExpiredAPIKey) {
+				return unauthorized(c, "API key is expired")
+			}
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				return unauthorized(c, "Invalid API key")
+			}
+			if err != nil {
+				logger.FromContext(c.Request().Context()).Error("Failed to authenticate API key:", err)
+				return unauthorized(c, "Failed to authenticate API key")
+			}
+
+			c.Set(ContextSubject, claims.Subject)
+			c.Set(ContextScopes, claims.Scopes)
+			c.SetRequest(c.Request().WithContext(auth.WithClaims(c.Request().Context(), claims)))
+			return next(c)
+		}
+	}
+}
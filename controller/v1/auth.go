@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"strings"
+	"user-service/pkg/auth"
+	"user-service/service"
+)
+
+const (
+	// ContextSubject и ContextScopes - ключи echo.Context с subject и scopes из токена
+	ContextSubject = "auth.subject"
+	ContextScopes  = "auth.scopes"
+
+	bearerPrefix = "Bearer "
+)
+
+// JWTAuth проверяет токен из заголовка Authorization: Bearer <token>. Без токена
+// доступны только пути из publicPaths, просроченный или некорректный токен - 401.
+// Запрос, уже аутентифицированный по API-ключу, пропускается. При verifier == nil
+// токены не принимаются и пройти можно только по API-ключу.
+func JWTAuth(verifier *auth.Verifier, publicPaths []string) echo.MiddlewareFunc {
+	public := make(map[string]struct{}, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = struct{}{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if _, ok := public[c.Request().URL.Path]; ok {
+				return next(c)
+			}
+			if _, ok := auth.ClaimsFromContext(c.Request().Context()); ok {
+				return next(c)
+			}
+
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+				return unauthorized(c, "Missing bearer token")
+			}
+			if verifier == nil {
+				return unauthorized(c, "Bearer tokens are not accepted")
+			}
+
+			claims, err := verifier.Verify(strings.TrimSpace(header[len(bearerPrefix):]))
+			if errors.Is(err, auth.ErrExpiredToken) {
+				return unauthorized(c, "Token is expired")
+			}
+			if err != nil {
+				return unauthorized(c, "Invalid token")
+			}
+
+			c.Set(ContextSubject, claims.Subject)
+			c.Set(ContextScopes, claims.Scopes)
+			c.SetRequest(c.Request().WithContext(auth.WithClaims(c.Request().Context(), claims)))
+			return next(c)
+		}
+	}
+}
+
+func unauthorized(c echo.Context, message string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="user-service"`)
+	return service.Unauthorized(message)
+}
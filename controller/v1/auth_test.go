@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-service/pkg/auth"
+	"user-service/service"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+// apiKeys принимает единственный ключ
+type apiKeys struct{}
+
+func (apiKeys) AuthenticateAPIKey(_ context.Context, secret string) (auth.Claims, error) {
+	if secret != "usk_valid" {
+		return auth.Claims{}, service.ErrInvalidAPIKey
+	}
+	return auth.Claims{Subject: "apikey:billing"}, nil
+}
+
+func TestJWTAuth(t *testing.T) {
+	verifier, err := auth.NewVerifier(auth.HMACSecret("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = service.HTTPErrorHandler
+	e.Use(APIKeyAuth(apiKeys{}), JWTAuth(verifier, []string{"/healthz"}))
+	e.GET("/healthz", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET("/users", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get(ContextSubject).(string))
+	})
+
+	token := func(exp time.Time) string {
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "exp": exp.Unix()}).SignedString([]byte("secret"))
+		return s
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		apiKey        string
+		want          int
+		wantSubject   string
+	}{
+		{"Health endpoint is public", "/healthz", "", "", http.StatusOK, ""},
+		{"Missing token", "/users", "", "", http.StatusUnauthorized, ""},
+		{"Malformed token", "/users", "Bearer garbage", "", http.StatusUnauthorized, ""},
+		{"Expired token", "/users", "Bearer " + token(time.Now().Add(-time.Minute)), "", http.StatusUnauthorized, ""},
+		{"Valid token", "/users", "Bearer " + token(time.Now().Add(time.Hour)), "", http.StatusOK, "alice"},
+		{"Valid API key", "/users", "", "usk_valid", http.StatusOK, "apikey:billing"},
+		{"Invalid API key", "/users", "Bearer " + token(time.Now().Add(time.Hour)), "usk_other", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(HeaderAPIKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if got := rec.Code; got != tt.want {
+				t.Errorf("got status %d, wanted %d", got, tt.want)
+			}
+			if tt.wantSubject != "" && rec.Body.String() != tt.wantSubject {
+				t.Errorf("got subject %q, wanted %q", rec.Body.String(), tt.wantSubject)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
+	"user-service/pkg/ratelimit"
+	"user-service/service"
+)
+
+// Заголовки лимитов по черновику IETF RateLimit header fields
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// RateLimits - лимит по умолчанию и лимиты отдельных маршрутов. Ключ маршрута - "METHOD /path"
+// в том виде, в котором путь зарегистрирован, например "GET /users/:id". Rate <= 0 снимает лимит.
+type RateLimits struct {
+	Default ratelimit.Limit
+	Routes  map[string]ratelimit.Limit
+}
+
+func (l RateLimits) forRoute(route string) ratelimit.Limit {
+	if limit, ok := l.Routes[route]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// RateLimit ограничивает частоту запросов клиента к каждому маршруту. Клиент определяется
+// по API-ключу, затем по subject токена, для неаутентифицированных запросов - по IP.
+// Если лимитер недоступен, запрос пропускается.
+func RateLimit(limiter ratelimit.Limiter, limits RateLimits) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := c.Request().Method + " " + strings.ReplaceAll(c.Path(), "\\", "")
+			limit := limits.forRoute(route)
+			if limit.Rate <= 0 || limit.Period <= 0 {
+				return next(c)
+			}
+
+			res, err := limiter.Allow(c.Request().Context(), route+":"+rateLimitClient(c), limit)
+			if err != nil {
+				logger.FromContext(c.Request().Context()).Warn("Rate limiter failed, request is not limited:", err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(res.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(res.Remaining))
+			header.Set(HeaderRateLimitReset, strconv.Itoa(seconds(res.ResetAfter)))
+			if !res.Allowed {
+				header.Set(echo.HeaderRetryAfter, strconv.Itoa(seconds(res.RetryAfter)))
+				return service.TooManyRequests("Too many requests")
+			}
+			return next(c)
+		}
+	}
+}
+
+func rateLimitClient(c echo.Context) string {
+	if claims, ok := auth.ClaimsFromContext(c.Request().Context()); ok {
+		if claims.APIKeyID != 0 {
+			return fmt.Sprintf("key:%d", claims.APIKeyID)
+		}
+		return "sub:" + claims.Subject
+	}
+	return "ip:" + c.RealIP()
+}
+
+// seconds округляет длительность вверх до целых секунд, как требуют Retry-After и RateLimit-Reset
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
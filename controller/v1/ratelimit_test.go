@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-service/pkg/auth"
+	"user-service/pkg/ratelimit"
+	"user-service/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRateLimit(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = service.HTTPErrorHandler
+	e.Use(RateLimit(ratelimit.NewMemory(), RateLimits{
+		Default: ratelimit.Limit{Rate: 100, Period: time.Second},
+		Routes: map[string]ratelimit.Limit{
+			"GET /users":     {Rate: 1, Period: time.Minute},
+			"GET /users/:id": {},
+		},
+	}))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/users", ok)
+	e.GET("/users/:id", ok)
+
+	call := func(path string, claims *auth.Claims) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if claims != nil {
+			req = req.WithContext(auth.WithClaims(req.Context(), *claims))
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Limit per client and route", func(t *testing.T) {
+		alice := &auth.Claims{Subject: "alice"}
+
+		rec := call("/users", alice)
+		if rec.Code != http.StatusOK || rec.Header().Get(HeaderRateLimitLimit) != "1" || rec.Header().Get(HeaderRateLimitRemaining) != "0" {
+			t.Errorf("unexpected first response %d %v", rec.Code, rec.Header())
+		}
+
+		rec = call("/users", alice)
+		if got, want := rec.Code, http.StatusTooManyRequests; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		if got := rec.Header().Get(echo.HeaderRetryAfter); got != "60" {
+			t.Errorf("got Retry-After %q, wanted 60", got)
+		}
+
+		// другой клиент и запрос без аутентификации считаются отдельно
+		if rec := call("/users", &auth.Claims{Subject: "bob", APIKeyID: 1}); rec.Code != http.StatusOK {
+			t.Errorf("got status %d for other client", rec.Code)
+		}
+		if rec := call("/users", nil); rec.Code != http.StatusOK {
+			t.Errorf("got status %d for anonymous client", rec.Code)
+		}
+	})
+
+	t.Run("Route without limit", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			rec := call("/users/1", &auth.Claims{Subject: "alice"})
+			if rec.Code != http.StatusOK || rec.Header().Get(HeaderRateLimitLimit) != "" {
+				t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+			}
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"github.com/labstack/echo/v4"
+	"user-service/pkg/auth"
+	"user-service/service"
+)
+
+// Authorize возвращает конструктор middleware, который пропускает запрос, только если роли клиента
+// дают указанное разрешение. При policy == nil проверка доступа выключена.
+func Authorize(policy *auth.Policy, service service.FIOServiceInterface) func(permission string) echo.MiddlewareFunc {
+	return func(permission string) echo.MiddlewareFunc {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			if policy == nil {
+				return next
+			}
+
+			return func(c echo.Context) error {
+				claims, ok := auth.ClaimsFromContext(c.Request().Context())
+				if !ok {
+					return unauthorized(c, "Authentication required")
+				}
+
+				permissions := policy.Permissions(claims)
+				ctx := auth.WithPermissions(c.Request().Context(), permissions)
+				c.SetRequest(c.Request().WithContext(ctx))
+
+				if !auth.Can(ctx, permission) {
+					return service.Forbidden(c, permission)
+				}
+				return next(c)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-service/pkg/auth"
+	"user-service/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// forbiddenService подменяет ответ на отказ в доступе и запоминает запрошенное разрешение
+type forbiddenService struct {
+	service.FIOServiceInterface
+	denied string
+}
+
+func (s *forbiddenService) Forbidden(c echo.Context, permission string) error {
+	s.denied = permission
+	return c.NoContent(http.StatusForbidden)
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := auth.NewPolicy(map[string][]string{
+		"reader": {auth.PermUsersRead},
+		"admin":  {auth.PermUsersRead, auth.PermUsersDelete},
+	})
+	svc := &forbiddenService{}
+	can := Authorize(policy, svc)
+
+	e := echo.New()
+	e.HTTPErrorHandler = service.HTTPErrorHandler
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/users", ok, can(auth.PermUsersRead))
+	e.DELETE("/users/:id", ok, can(auth.PermUsersDelete))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		claims     *auth.Claims
+		want       int
+		wantDenied string
+	}{
+		{"Unauthenticated", http.MethodGet, "/users", nil, http.StatusUnauthorized, ""},
+		{"Reader lists users", http.MethodGet, "/users", &auth.Claims{Subject: "bob", Scopes: []string{"reader"}}, http.StatusOK, ""},
+		{"Reader cannot delete", http.MethodDelete, "/users/1", &auth.Claims{Subject: "bob", Scopes: []string{"reader"}}, http.StatusForbidden, auth.PermUsersDelete},
+		{"Admin deletes", http.MethodDelete, "/users/1", &auth.Claims{Subject: "alice", Roles: []string{"admin"}}, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.denied = ""
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.claims != nil {
+				req = req.WithContext(auth.WithClaims(req.Context(), *tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if got := rec.Code; got != tt.want {
+				t.Errorf("got status %d, wanted %d", got, tt.want)
+			}
+			if svc.denied != tt.wantDenied {
+				t.Errorf("got denied permission %q, wanted %q", svc.denied, tt.wantDenied)
+			}
+		})
+	}
+}
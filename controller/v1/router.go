@@ -3,37 +3,47 @@ package v1
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	log "github.com/sirupsen/logrus"
-	"os"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
 	"user-service/service"
 )
 
-func NewRouter(handler *echo.Echo, service service.FIOServiceInterface) {
-	handler.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: `{"time":"${time_rfc3339_nano}", "method":"${method}","uri":"${uri}", "status":${status},"error":"${error}"}` + "\n",
-		Output: setLogsFile(),
-	}))
+// NewRouter регистрирует эндпоинты. Дополнительные middlewares (аутентификация и т.п.)
+// выполняются после присвоения X-Request-ID (входящий идентификатор сохраняется), журнала запросов
+// и восстановления после паники. accessLog - middleware журнала запросов, nil выключает журнал.
+// Доступ к каждому эндпоинту проверяется по policy, nil выключает проверку.
+func NewRouter(handler *echo.Echo, service service.FIOServiceInterface, policy *auth.Policy, accessLog echo.MiddlewareFunc,
+	middlewares ...echo.MiddlewareFunc) {
+	handler.Use(logger.Middleware())
+	if accessLog != nil {
+		handler.Use(accessLog)
+	}
 	handler.Use(middleware.Recover())
+	handler.Use(middlewares...)
 
-	handler.GET("/users", service.GetUsers)
-	handler.POST("/users", service.AddUser)
-	handler.DELETE("/users/:id", service.DeleteUser)
-	handler.PUT("/users/:id", service.UpdateUser)
+	can := Authorize(policy, service)
 
-}
+	handler.GET("/users", service.GetUsers, can(auth.PermUsersRead))
+	handler.GET("/users/export", service.ExportUsers, can(auth.PermUsersRead))
+	handler.GET("/users/search", service.SearchUsers, can(auth.PermUsersRead))
+	handler.GET("/users/stats", service.GetUserStats, can(auth.PermUsersRead))
+	handler.GET("/users/:id", service.GetUser, can(auth.PermUsersRead))
+	handler.POST("/users", service.AddUser, can(auth.PermUsersWrite))
+	handler.POST("/users\\:batch", service.BatchUsers, can(auth.PermUsersWrite))
+	handler.DELETE("/users/:id", service.DeleteUser, can(auth.PermUsersDelete))
+	handler.PUT("/users/:id", service.UpdateUser, can(auth.PermUsersWrite))
+	handler.POST("/users/:id/restore", service.RestoreUser, can(auth.PermUsersDelete))
+	handler.DELETE("/users/:id/purge", service.PurgeUser, can(auth.PermUsersDelete))
+	handler.GET("/users/:id/history", service.GetUserHistory, can(auth.PermUsersRead))
+	handler.GET("/users/:id/duplicates", service.GetDuplicates, can(auth.PermUsersRead))
+	handler.POST("/users/:id/merge", service.MergeUsers, can(auth.PermUsersDelete))
 
-func setLogsFile() *os.File {
-	dir := "/logs"
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		err := os.MkdirAll(dir, 0755)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
+	handler.POST("/imports", service.CreateImport, can(auth.PermUsersWrite))
+	handler.GET("/imports/:id", service.GetImport, can(auth.PermUsersRead))
+
+	handler.POST("/admin/api-keys", service.IssueAPIKey, can(auth.PermAPIKeysManage))
+	handler.GET("/admin/api-keys", service.ListAPIKeys, can(auth.PermAPIKeysManage))
+	handler.POST("/admin/api-keys/:id/rotate", service.RotateAPIKey, can(auth.PermAPIKeysManage))
+	handler.DELETE("/admin/api-keys/:id", service.RevokeAPIKey, can(auth.PermAPIKeysManage))
 
-	file, err := os.OpenFile(dir+"/requests.log", os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return file
 }
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"io"
+	"os"
+	"user-service/config"
+	"user-service/pkg/accesslog"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	accessLogStdout = "stdout"
+	accessLogFile   = "file"
+	accessLogOff    = "off"
+)
+
+// newAccessLog создает middleware журнала запросов по конфигурации, nil - журнал выключен.
+// Если файл журнала нельзя открыть (например, в контейнере с файловой системой только для чтения),
+// журнал пишется в stdout. Возвращаемую функцию нужно вызвать при остановке.
+func newAccessLog(cfg config.AccessLog) (echo.MiddlewareFunc, func()) {
+	var out io.Writer
+	closeLog := func() {}
+	switch cfg.Output {
+	case accessLogOff:
+		log.Info("Access log is disabled")
+		return nil, closeLog
+	case accessLogStdout:
+		out = os.Stdout
+	case accessLogFile:
+		file, err := accesslog.NewFile(cfg.Path, cfg.MaxSize, cfg.MaxAge, cfg.MaxBackups, cfg.Compress)
+		if err != nil {
+			log.Errorf("Failed to open access log %s, writing to stdout: %s", cfg.Path, err)
+			out = os.Stdout
+			break
+		}
+		out = file
+		closeLog = func() {
+			if err := file.Close(); err != nil {
+				log.Error("Failed to close access log:", err)
+			}
+		}
+	default:
+		log.Fatalf("unknown access log output: %s", cfg.Output)
+	}
+
+	accessLog, err := accesslog.New(out, accesslog.Fields(cfg.Fields...), accesslog.Redact(cfg.Redact...))
+	if err != nil {
+		log.Fatal("failed to init access log: ", err)
+	}
+	return accessLog.Middleware(), closeLog
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"net/http"
+	"user-service/config"
+	"user-service/pkg/cache"
+	"user-service/pkg/health"
+	"user-service/pkg/httpserver"
+	"user-service/pkg/metrics"
+	"user-service/pkg/psql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newAdminServer запускает служебный сервер с /metrics на отдельном порту, чтобы метрики
+// не были доступны снаружи вместе с API и не проходили аутентификацию и ограничение частоты.
+// Пробы /healthz и /readyz доступны и здесь, и на порту API.
+func newAdminServer(cfg config.Admin, storage *psql.Postgres, loader *cache.ReadThrough, checker *health.Checker) *httpserver.Server {
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(storage.Stat))
+	registerLoaderMetrics(loader)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", checker.LiveHandler())
+	mux.Handle("/readyz", checker.ReadyHandler())
+	return httpserver.New(mux, httpserver.Port(cfg.Port))
+}
+
+// registerLoaderMetrics экспортирует попадания и промахи сквозного кэша читающих эндпоинтов
+func registerLoaderMetrics(loader *cache.ReadThrough) {
+	const help = "Cached reads by result: hit, stale (served while refreshing) or miss (loaded from the database)."
+	metrics.CounterFunc("cache", "requests_total", help, prometheus.Labels{"result": "hit"}, func() float64 {
+		return float64(loader.Stats().Hits)
+	})
+	metrics.CounterFunc("cache", "requests_total", help, prometheus.Labels{"result": "stale"}, func() float64 {
+		return float64(loader.Stats().Stale)
+	})
+	metrics.CounterFunc("cache", "requests_total", help, prometheus.Labels{"result": "miss"}, func() float64 {
+		return float64(loader.Stats().Misses)
+	})
+}
+
+// registerStoreMetrics экспортирует ошибки Redis и, если включен локальный уровень, попадания по уровням
+func registerStoreMetrics(fallback *cache.Fallback, tiered *cache.Tiered) {
+	metrics.CounterFunc("cache", "errors_total", "Redis errors served from the fallback cache.", nil, func() float64 {
+		return float64(fallback.Errors())
+	})
+	if tiered == nil {
+		return
+	}
+
+	const help = "Cache store lookups by layer and result."
+	for _, counter := range []struct {
+		layer, result string
+		value         func(cache.Stats) uint64
+	}{
+		{"local", "hit", func(s cache.Stats) uint64 { return s.LocalHits }},
+		{"local", "miss", func(s cache.Stats) uint64 { return s.LocalMisses }},
+		{"remote", "hit", func(s cache.Stats) uint64 { return s.RemoteHits }},
+		{"remote", "miss", func(s cache.Stats) uint64 { return s.RemoteMisses }},
+	} {
+		counter := counter
+		metrics.CounterFunc("cache", "layer_requests_total", help, prometheus.Labels{"layer": counter.layer, "result": counter.result},
+			func() float64 {
+				return float64(counter.value(tiered.Stats()))
+			})
+	}
+}
@@ -1,17 +1,22 @@
 package app
 
 import (
+	"context"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 	"user-service/config"
 	v1 "user-service/controller/v1"
+	"user-service/pkg/cache"
 	"user-service/pkg/httpserver"
 	"user-service/pkg/kafka"
+	"user-service/pkg/metrics"
 	"user-service/pkg/psql"
 	"user-service/pkg/redis"
+	"user-service/pkg/tracing"
 	"user-service/repo/pgdb"
 	"user-service/service"
 )
@@ -25,6 +30,12 @@ type Person struct {
 	Nationality string `json:"nationality,omitempty"`
 }
 
+// tracingShutdownTimeout - сколько ждать отправки накопленных спанов при остановке
+const tracingShutdownTimeout = 5 * time.Second
+
+// importShutdownTimeout - сколько ждать, пока прерванные импорты сохранят свое состояние
+const importShutdownTimeout = 15 * time.Second
+
 func Run() {
 	// конфигурации
 	cfg := config.LoadConfig()
@@ -32,6 +43,23 @@ func Run() {
 	// Logger
 	SetLogrus(cfg.Log.Level)
 
+	// Трассировка, спаны создаются и для запросов к базе и Redis, поэтому настраивается первой
+	shutdownTracing, err := tracing.Setup(cfg.Tracing.ServiceName,
+		tracing.Exporter(cfg.Tracing.Exporter),
+		tracing.Endpoint(cfg.Tracing.Endpoint),
+		tracing.SampleRatio(cfg.Tracing.SampleRatio),
+	)
+	if err != nil {
+		log.Fatal("failed to init tracing: ", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("Failed to flush traces:", err)
+		}
+	}()
+
 	// Создаем подключение к  PostgreSQL
 	storage, err := psql.New(cfg.ConnectionString, psql.MaxPoolSize(cfg.MaxPoolSize))
 	if err != nil {
@@ -48,27 +76,67 @@ func Run() {
 		}
 	}()
 
-	// Инициализируем редис
+	// Инициализируем редис, соединение устанавливается при первом обращении
 	redisClient := redis.New(cfg.Redis.Addr, cfg.Redis.Password)
+	defer redisClient.Close()
+
+	// Инициализируем кэш, без Redis сервис работает с запасным хранилищем
+	cacheStore, closeCache := newCacheStore(cfg.Redis, redisClient)
+	defer closeCache()
 
 	// создаем экземпляр сервиса с зависимостями
 	userRepo := pgdb.NewUserRepo(storage)
-	fioService := service.NewFIOService(kafkaService, userRepo, redisClient)
+	importRepo := pgdb.NewImportRepo(storage)
+	apiKeyRepo := pgdb.NewAPIKeyRepo(storage)
+	outboxRepo := pgdb.NewOutboxRepo(storage)
+	cacheLoader := cache.NewReadThrough(cacheStore, cacheStore,
+		cache.StaleWhileRevalidate(cfg.Redis.StaleWindow),
+		cache.LockTTL(cfg.Redis.LockTTL),
+	)
+	names, latin := newNames(cfg.Names)
+	fioService := service.NewFIOService(kafkaService, userRepo, importRepo, apiKeyRepo, outboxRepo, cacheStore, cacheLoader,
+		names, latin, service.Duplicates{Threshold: cfg.Duplicates.Threshold, CandidateLimit: cfg.Duplicates.CandidateLimit},
+		service.Stats{AgeBucket: cfg.Stats.AgeBucket})
 
 	// запускаем основной цикл обработки сообщений
 	go fioService.ProcessMessages()
 
+	// запускаем окончательное удаление пользователей с истекшим сроком хранения
+	go fioService.RunRetention(cfg.Retention.Period, cfg.Retention.Interval)
+
+	// публикуем события outbox
+	go fioService.RunOutboxRelay(cfg.Outbox.Topic, cfg.Outbox.RelayInterval, cfg.Outbox.BatchSize)
+
+	// сохраняем счетчики использования API-ключей
+	go fioService.RunAPIKeyUsageFlush(cfg.Auth.APIKeyUsageFlush)
+
 	// Echo
 	log.Info("Initializing handlers and routes...")
 	handler := echo.New()
-
-	// эндпоинты
-	v1.NewRouter(handler, fioService)
+	// ошибки обработчиков отдаются в формате application/problem+json
+	handler.HTTPErrorHandler = service.HTTPErrorHandler
+
+	// эндпоинты, спаны и метрики запросов снимаются до аутентификации, чтобы учитывать и отказы
+	middlewares := []echo.MiddlewareFunc{tracing.Middleware(service.ErrorStatus), metrics.Middleware(service.ErrorStatus)}
+	middlewares = append(middlewares, newAuthMiddlewares(cfg.Auth, fioService)...)
+	middlewares = append(middlewares, newRateLimitMiddlewares(cfg.RateLimit, redisClient)...)
+	accessLog, closeAccessLog := newAccessLog(cfg.AccessLog)
+	defer closeAccessLog()
+	v1.NewRouter(handler, fioService, newPolicy(cfg.Auth, cfg.RBAC), accessLog, middlewares...)
+
+	// пробы Kubernetes, аутентификация для них отключена через auth.public_paths
+	checker := newHealthChecker(cfg, storage, kafkaService, redisClient)
+	handler.GET("/healthz", echo.WrapHandler(checker.LiveHandler()))
+	handler.GET("/readyz", echo.WrapHandler(checker.ReadyHandler()))
 
 	// HTTP сервер
 	log.Info("Starting http server...")
 	httpServer := httpserver.New(handler, httpserver.Port(cfg.Port))
 
+	// служебный сервер с метриками
+	log.Info("Starting admin server...")
+	adminServer := newAdminServer(cfg.Admin, storage, cacheLoader, checker)
+
 	log.Info("Configuring graceful shutdown...")
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
@@ -78,12 +146,26 @@ func Run() {
 		log.Info("app - Run - signal: " + s.String())
 	case err = <-httpServer.Notify():
 		log.Error("app - Run - httpServer.Notify: %w", err)
+	case err = <-adminServer.Notify():
+		log.Error("app - Run - adminServer.Notify: %w", err)
 	}
 
+	// /readyz начинает отвечать 503, запросы продолжают обслуживаться, пока балансировщик не уберет экземпляр
+	log.Infof("Draining for %s...", cfg.Health.ShutdownDelay)
+	checker.Shutdown()
+	time.Sleep(cfg.Health.ShutdownDelay)
+
 	log.Info("Shutting down...")
+	fioService.Stop()
+	if !fioService.WaitImports(importShutdownTimeout) {
+		log.Warn("Imports did not stop in time")
+	}
 	err = httpServer.Shutdown()
 	if err != nil {
 		log.Error("app - Run - httpServer.Shutdown: %w", err)
 	}
+	if err := adminServer.Shutdown(); err != nil {
+		log.Error("app - Run - adminServer.Shutdown: %w", err)
+	}
 
 }
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"user-service/config"
+	v1 "user-service/controller/v1"
+	"user-service/pkg/auth"
+)
+
+// newAuthMiddlewares создает проверку API-ключей и JWT, при выключенной аутентификации API открыт всем.
+// Если ключи для JWT не заданы, пройти можно только по API-ключу.
+func newAuthMiddlewares(cfg config.Auth, apiKeys v1.APIKeyAuthenticator) []echo.MiddlewareFunc {
+	if !cfg.Enabled {
+		log.Warn("Authentication is disabled, API is open to everyone")
+		return nil
+	}
+
+	var verifier *auth.Verifier
+	if cfg.Secret != "" || cfg.JWKSFile != "" {
+		var err error
+		verifier, err = auth.NewVerifier(
+			auth.HMACSecret(cfg.Secret),
+			auth.JWKSFile(cfg.JWKSFile),
+			auth.Issuer(cfg.Issuer),
+			auth.Audience(cfg.Audience),
+		)
+		if err != nil {
+			log.Fatalf("failed to init token verifier: %s", err)
+		}
+	} else {
+		log.Warn("No JWT keys configured, only API keys are accepted")
+	}
+
+	return []echo.MiddlewareFunc{v1.APIKeyAuth(apiKeys), v1.JWTAuth(verifier, cfg.PublicPaths)}
+}
+
+// newPolicy создает политику доступа. Без аутентификации роли клиента неизвестны, поэтому
+// проверка доступа тоже выключается.
+func newPolicy(authCfg config.Auth, cfg config.RBAC) *auth.Policy {
+	if !cfg.Enabled {
+		log.Warn("Access control is disabled, any authenticated client can call any endpoint")
+		return nil
+	}
+	if !authCfg.Enabled {
+		log.Warn("Access control requires authentication and is disabled")
+		return nil
+	}
+	return auth.NewPolicy(cfg.Roles)
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	goredis "github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+	"user-service/config"
+	"user-service/pkg/cache"
+	"user-service/pkg/redis"
+)
+
+const (
+	cacheBackendRedis  = "redis"
+	cacheBackendMemory = "memory"
+	cacheBackendNone   = "none"
+)
+
+// newCacheStore создает хранилище кэша по конфигурации. Пока Redis не отвечает, при запуске
+// или во время работы, сервис работает с запасным хранилищем и раз в RetryInterval проверяет Redis снова.
+// Возвращаемую функцию нужно вызвать при остановке, клиент Redis закрывает вызывающий.
+func newCacheStore(cfg config.Redis, client *goredis.Client) (cache.LockingCache, func()) {
+	switch cfg.Backend {
+	case cacheBackendNone, cacheBackendMemory:
+		log.Infof("Using %s cache", cfg.Backend)
+		return newLocalStore(cfg.Backend, cfg.LocalCache), func() {}
+	case cacheBackendRedis:
+	default:
+		log.Fatalf("unknown cache backend: %s", cfg.Backend)
+	}
+
+	var store cache.LockingCache = cache.NewRedisCache(client)
+	var tiered *cache.Tiered
+	if cfg.LocalCache.Enabled {
+		tiered = cache.NewTiered(client,
+			cache.NewLRU(cfg.LocalCache.MaxEntries, cfg.LocalCache.MaxBytes),
+			cache.LocalTTL(cfg.LocalCache.TTL),
+			cache.InvalidationChannel(cfg.LocalCache.Channel),
+		)
+		store = tiered
+	}
+	fallback := cache.NewFallback(store, newLocalStore(cfg.Fallback, cfg.LocalCache), cfg.RetryInterval)
+	if err := redis.Ping(client, cfg.PingTimeout); err != nil {
+		log.Errorf("Redis is unavailable, falling back to %s cache: %s", cfg.Fallback, err)
+		fallback.MarkDown(err)
+	}
+	registerStoreMetrics(fallback, tiered)
+
+	return fallback, func() {
+		fields := log.Fields{"errors": fallback.Errors()}
+		if tiered != nil {
+			stats := tiered.Stats()
+			fields["local_hits"] = stats.LocalHits
+			fields["local_misses"] = stats.LocalMisses
+			fields["remote_hits"] = stats.RemoteHits
+			fields["remote_misses"] = stats.RemoteMisses
+			fields["invalidations"] = stats.Invalidations
+			fields["evictions"] = stats.Evictions
+			if err := tiered.Close(); err != nil {
+				log.Error("Failed to close cache invalidation subscription:", err)
+			}
+		}
+		log.WithFields(fields).Info("Cache stats")
+	}
+}
+
+// newLocalStore создает хранилище, которое не требует Redis
+func newLocalStore(backend string, cfg config.LocalCache) cache.LockingCache {
+	if backend == cacheBackendMemory {
+		return cache.NewMemory(cfg.MaxEntries, cfg.MaxBytes)
+	}
+	return cache.Noop{}
+}
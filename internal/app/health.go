@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"user-service/config"
+	"user-service/pkg/health"
+	"user-service/pkg/kafka"
+	"user-service/pkg/psql"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// newHealthChecker собирает проверки готовности. Без Postgres и брокеров Kafka сервис не может ни отвечать
+// на запросы, ни принимать сообщения, поэтому эти проверки критичные. Redis некритичный: кэш и ограничение
+// частоты переходят на локальные хранилища. Группа потребителя тоже некритичная: перебалансировка
+// не должна снимать с экземпляра HTTP-трафик.
+func newHealthChecker(cfg *config.Config, storage *psql.Postgres, kafkaService *kafka.Service, redisClient *goredis.Client) *health.Checker {
+	checker := health.New(health.Timeout(cfg.Health.Timeout))
+	checker.Add("postgres", true, storage.Ping)
+	checker.Add("kafka", true, kafkaService.Ping)
+	checker.Add("kafka_consumer_group", false, kafkaService.CheckGroup)
+	if cfg.Redis.Backend == cacheBackendRedis || (cfg.RateLimit.Enabled && cfg.RateLimit.Backend == "redis") {
+		checker.Add("redis", false, func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+	return checker
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"flag"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"syscall"
+	"user-service/api_clients/model"
+	"user-service/config"
+	"user-service/pkg/kafka"
+	"user-service/pkg/psql"
+	"user-service/pkg/redis"
+	"user-service/repo/pgdb"
+	"user-service/service"
+)
+
+// RunImport - подкоманда import: синхронно импортирует файл ФИО тем же путем, что и POST /imports.
+// Задача сохраняется в БД, поэтому ее статус и ошибки видны в GET /imports/:id.
+func RunImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "формат файла: csv или ndjson (по умолчанию определяется по расширению)")
+	mode := flags.String("mode", service.ImportModeEnrich, "enrich - обогатить и сохранить, publish - опубликовать в топик ФИО")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatal("usage: app import [-format csv|ndjson] [-mode enrich|publish] <file>")
+	}
+	path := flags.Arg(0)
+
+	cfg := config.LoadConfig()
+	SetLogrus(cfg.Log.Level)
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal("failed to open import file: ", err)
+	}
+	defer file.Close()
+
+	storage, err := psql.New(cfg.ConnectionString, psql.MaxPoolSize(cfg.MaxPoolSize))
+	if err != nil {
+		log.Fatal("failed to init storage: ", err)
+	}
+	defer storage.Close()
+
+	kafkaService := kafka.New(cfg.Kafka.Brokers, cfg.Kafka.Topic)
+	defer kafkaService.Close()
+
+	redisClient := redis.New(cfg.Redis.Addr, cfg.Redis.Password)
+	defer redisClient.Close()
+	cacheStore, closeCache := newCacheStore(cfg.Redis, redisClient)
+	defer closeCache()
+	names, latin := newNames(cfg.Names)
+	fioService := service.NewFIOService(kafkaService, pgdb.NewUserRepo(storage), pgdb.NewImportRepo(storage), pgdb.NewAPIKeyRepo(storage),
+		pgdb.NewOutboxRepo(storage), cacheStore, nil, names, latin, service.Duplicates{}, service.Stats{})
+
+	// по сигналу остановки импорт прерывается между строками и сохраняет статус задачи
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	imp, err := fioService.NewImport(ctx, path, *format, *mode)
+	if err != nil {
+		log.Fatal("failed to create import: ", err)
+	}
+
+	log.Infof("Import %d started: %s (%s, %s)", imp.ID, imp.Filename, imp.Format, imp.Mode)
+	imp = fioService.RunImport(ctx, imp, file)
+	log.WithFields(log.Fields{
+		"id":        imp.ID,
+		"status":    imp.Status,
+		"processed": imp.ProcessedRows,
+		"failed":    imp.FailedRows,
+	}).Info("Import finished")
+
+	if imp.Status == model.ImportFailed {
+		log.Error("Import failed: ", imp.Error)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	log "github.com/sirupsen/logrus"
+	"user-service/config"
+	"user-service/pkg/normalize"
+	"user-service/pkg/translit"
+	"user-service/service"
+)
+
+// newNames создает нормализацию ФИО и настройки транслитерации для обогащения и поиска
+func newNames(cfg config.Names) (*normalize.Normalizer, service.Transliteration) {
+	scheme, err := translit.Lookup(cfg.Transliteration)
+	if err != nil {
+		log.Fatal("failed to init transliteration: ", err)
+	}
+	log.Infof("Names: language %s, transliteration %s, store latin %t", cfg.Language, scheme.Name(), cfg.StoreLatin)
+	return normalize.New(normalize.Language(cfg.Language)), service.Transliteration{Scheme: scheme, StoreLatin: cfg.StoreLatin}
+}
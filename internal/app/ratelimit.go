@@ -0,0 +1,42 @@
+package app
+
+import (
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"user-service/config"
+	v1 "user-service/controller/v1"
+	"user-service/pkg/ratelimit"
+)
+
+// newRateLimitMiddlewares создает ограничение частоты запросов. Его нужно ставить после аутентификации,
+// чтобы лимит считался по клиенту, а не по IP.
+func newRateLimitMiddlewares(cfg config.RateLimit, client *goredis.Client) []echo.MiddlewareFunc {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var limiter ratelimit.Limiter
+	switch cfg.Backend {
+	case "memory":
+		limiter = ratelimit.NewMemory()
+	case "redis":
+		limiter = ratelimit.NewFallback(ratelimit.NewRedis(client), ratelimit.NewMemory())
+	default:
+		log.Fatalf("unknown rate limit backend: %s", cfg.Backend)
+	}
+
+	limits := v1.RateLimits{
+		Default: routeLimit(cfg.Default),
+		Routes:  make(map[string]ratelimit.Limit, len(cfg.Routes)),
+	}
+	for route, limit := range cfg.Routes {
+		limits.Routes[route] = routeLimit(limit)
+	}
+
+	return []echo.MiddlewareFunc{v1.RateLimit(limiter, limits)}
+}
+
+func routeLimit(cfg config.RouteLimit) ratelimit.Limit {
+	return ratelimit.Limit{Rate: cfg.Rate, Period: cfg.Period, Burst: cfg.Burst}
+}
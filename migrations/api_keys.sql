@@ -0,0 +1,17 @@
+CREATE TABLE api_keys (
+                          id BIGSERIAL PRIMARY KEY,
+                          name TEXT NOT NULL,
+                          owner TEXT NOT NULL,
+                          roles TEXT[] NOT NULL DEFAULT '{}',
+                          prefix TEXT NOT NULL,
+                          key_hash TEXT NOT NULL UNIQUE,
+                          usage_count BIGINT NOT NULL DEFAULT 0,
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                          last_used_at TIMESTAMPTZ,
+                          expires_at TIMESTAMPTZ,
+                          revoked_at TIMESTAMPTZ
+);
+
+-- down.sql
+
+DROP TABLE api_keys;
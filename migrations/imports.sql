@@ -0,0 +1,26 @@
+CREATE TABLE imports (
+                         id BIGSERIAL PRIMARY KEY,
+                         filename TEXT NOT NULL,
+                         format TEXT NOT NULL,
+                         mode TEXT NOT NULL,
+                         status TEXT NOT NULL,
+                         processed_rows INT NOT NULL DEFAULT 0,
+                         failed_rows INT NOT NULL DEFAULT 0,
+                         error TEXT,
+                         created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                         finished_at TIMESTAMPTZ
+);
+
+CREATE TABLE import_errors (
+                               import_id BIGINT NOT NULL REFERENCES imports (id) ON DELETE CASCADE,
+                               row_number INT NOT NULL,
+                               error TEXT NOT NULL,
+                               raw TEXT
+);
+
+CREATE INDEX import_errors_import_id_idx ON import_errors (import_id, row_number);
+
+-- down.sql
+
+DROP TABLE import_errors;
+DROP TABLE imports;
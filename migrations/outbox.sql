@@ -0,0 +1,16 @@
+CREATE TABLE outbox (
+                        id BIGSERIAL PRIMARY KEY,
+                        aggregate TEXT NOT NULL,
+                        aggregate_id INT NOT NULL,
+                        event_type TEXT NOT NULL,
+                        payload JSONB NOT NULL,
+                        created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+                        published_at TIMESTAMPTZ
+);
+
+-- неопубликованные события читаются по порядку
+CREATE INDEX outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+
+-- down.sql
+
+DROP TABLE outbox;
@@ -0,0 +1,6 @@
+-- контекст трассировки и идентификатор запроса, в котором записано событие, relay публикует их в заголовках
+ALTER TABLE outbox ADD COLUMN headers JSONB NOT NULL DEFAULT '{}';
+
+-- down.sql
+
+ALTER TABLE outbox DROP COLUMN headers;
@@ -0,0 +1,16 @@
+CREATE TABLE user_audit (
+                            id BIGSERIAL PRIMARY KEY,
+                            user_id INT,
+                            actor TEXT NOT NULL,
+                            source TEXT NOT NULL,
+                            operation TEXT NOT NULL,
+                            before JSONB,
+                            after JSONB,
+                            created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_audit_user_id_idx ON user_audit (user_id, id);
+
+-- down.sql
+
+DROP TABLE user_audit;
@@ -0,0 +1,5 @@
+ALTER TABLE user_audit ADD COLUMN details JSONB;
+
+-- down.sql
+
+ALTER TABLE user_audit DROP COLUMN details;
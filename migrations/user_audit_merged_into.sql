@@ -0,0 +1,6 @@
+-- история пользователя включает журналы слитых в него дубликатов, они находятся по details.merged_into
+CREATE INDEX user_audit_merged_into_idx ON user_audit (((details->>'merged_into')::int)) WHERE operation = 'merge';
+
+-- down.sql
+
+DROP INDEX user_audit_merged_into_idx;
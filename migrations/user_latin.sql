@@ -0,0 +1,9 @@
+-- ФИО латиницей для поиска, заполняется при names.store_latin
+ALTER TABLE users ADD COLUMN latin TEXT;
+
+CREATE INDEX users_latin_idx ON users (lower(latin) text_pattern_ops);
+
+-- down.sql
+
+DROP INDEX users_latin_idx;
+ALTER TABLE users DROP COLUMN latin;
@@ -0,0 +1,6 @@
+-- btree-индекс из user_latin.sql не подходит для LIKE '%x%' и поиска, его заменяет users_latin_trgm_idx из user_search.sql
+DROP INDEX users_latin_idx;
+
+-- down.sql
+
+CREATE INDEX users_latin_idx ON users (lower(latin) text_pattern_ops);
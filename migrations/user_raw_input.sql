@@ -0,0 +1,6 @@
+-- исходные значения ФИО до нормализации
+ALTER TABLE users ADD COLUMN raw_input JSONB;
+
+-- down.sql
+
+ALTER TABLE users DROP COLUMN raw_input;
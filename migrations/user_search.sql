@@ -0,0 +1,15 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- поиск по полному ФИО в любом порядке слов: триграммы строятся по каждому слову отдельно
+CREATE INDEX users_fio_trgm_idx ON users
+    USING gin ((lower(surname || ' ' || name || ' ' || COALESCE(patronymic, ''))) gin_trgm_ops);
+CREATE INDEX users_latin_trgm_idx ON users USING gin ((lower(latin)) gin_trgm_ops);
+
+-- фильтр GET /users?filter= (name LIKE '%x%') тоже использует триграммный индекс
+CREATE INDEX users_name_trgm_idx ON users USING gin (name gin_trgm_ops);
+
+-- down.sql
+
+DROP INDEX users_name_trgm_idx;
+DROP INDEX users_latin_trgm_idx;
+DROP INDEX users_fio_trgm_idx;
@@ -0,0 +1,8 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- down.sql
+
+DROP INDEX users_deleted_at_idx;
+ALTER TABLE users DROP COLUMN deleted_at;
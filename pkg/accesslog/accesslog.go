@@ -0,0 +1,181 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Поля журнала запросов
+const (
+	FieldTime      = "time"
+	FieldMethod    = "method"
+	FieldURI       = "uri"
+	FieldStatus    = "status"
+	FieldLatency   = "latency"
+	FieldBytesIn   = "bytes_in"
+	FieldBytesOut  = "bytes_out"
+	FieldUserID    = "user_id"
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldRemoteIP  = "remote_ip"
+	FieldUserAgent = "user_agent"
+	FieldError     = "error"
+)
+
+// redacted заменяет значения скрытых параметров запроса
+const redacted = "REDACTED"
+
+// entry - данные одного запроса, из которых берутся выбранные поля
+type entry struct {
+	start   time.Time
+	stop    time.Time
+	c       echo.Context
+	err     error
+	bytesIn int64
+}
+
+// fields - значения полей журнала. Ключ в JSON совпадает с именем поля, кроме latency (latency_ms).
+var fields = map[string]func(l *Logger, e entry) (string, interface{}){
+	FieldTime:   func(_ *Logger, e entry) (string, interface{}) { return FieldTime, e.start.Format(time.RFC3339Nano) },
+	FieldMethod: func(_ *Logger, e entry) (string, interface{}) { return FieldMethod, e.c.Request().Method },
+	FieldURI:    func(l *Logger, e entry) (string, interface{}) { return FieldURI, l.redactURI(e.c.Request().RequestURI) },
+	FieldStatus: func(_ *Logger, e entry) (string, interface{}) { return FieldStatus, e.c.Response().Status },
+	FieldLatency: func(_ *Logger, e entry) (string, interface{}) {
+		return "latency_ms", float64(e.stop.Sub(e.start).Microseconds()) / 1000
+	},
+	FieldBytesIn:  func(_ *Logger, e entry) (string, interface{}) { return FieldBytesIn, e.bytesIn },
+	FieldBytesOut: func(_ *Logger, e entry) (string, interface{}) { return FieldBytesOut, e.c.Response().Size },
+	FieldUserID: func(_ *Logger, e entry) (string, interface{}) {
+		claims, _ := auth.ClaimsFromContext(e.c.Request().Context())
+		return FieldUserID, claims.Subject
+	},
+	FieldRequestID: func(_ *Logger, e entry) (string, interface{}) {
+		return FieldRequestID, logger.RequestID(e.c.Request().Context())
+	},
+	FieldTraceID: func(_ *Logger, e entry) (string, interface{}) {
+		sc := trace.SpanContextFromContext(e.c.Request().Context())
+		if !sc.IsValid() {
+			return FieldTraceID, ""
+		}
+		return FieldTraceID, sc.TraceID().String()
+	},
+	FieldRemoteIP:  func(_ *Logger, e entry) (string, interface{}) { return FieldRemoteIP, e.c.RealIP() },
+	FieldUserAgent: func(_ *Logger, e entry) (string, interface{}) { return FieldUserAgent, e.c.Request().UserAgent() },
+	FieldError: func(_ *Logger, e entry) (string, interface{}) {
+		if e.err == nil {
+			return FieldError, ""
+		}
+		return FieldError, e.err.Error()
+	},
+}
+
+// Logger пишет журнал запросов: по строке JSON на запрос с выбранными полями
+type Logger struct {
+	out    io.Writer
+	fields []string
+	redact map[string]bool
+
+	mu sync.Mutex
+}
+
+// New создает журнал запросов, который пишет в out. Неизвестное поле в Fields - ошибка.
+func New(out io.Writer, opts ...Option) (*Logger, error) {
+	l := &Logger{
+		out:    out,
+		fields: DefaultFields,
+		redact: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	for _, field := range l.fields {
+		if _, ok := fields[field]; !ok {
+			return nil, fmt.Errorf("unknown access log field: %s", field)
+		}
+	}
+	return l, nil
+}
+
+// DefaultFields - поля журнала по умолчанию
+var DefaultFields = []string{FieldTime, FieldMethod, FieldURI, FieldStatus, FieldLatency, FieldRequestID, FieldError}
+
+// Middleware записывает строку журнала после обработки запроса. Ошибка обработчика передается
+// в HTTPErrorHandler здесь же, чтобы в журнал попал итоговый статус ответа.
+func (l *Logger) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			e := entry{start: time.Now(), c: c, bytesIn: c.Request().ContentLength}
+			if e.err = next(c); e.err != nil {
+				c.Error(e.err)
+			}
+			e.stop = time.Now()
+			if err := l.write(e); err != nil {
+				log.Error("Failed to write access log:", err)
+			}
+			return nil
+		}
+	}
+}
+
+func (l *Logger) write(e entry) error {
+	// ключи и значения пишутся по одному, чтобы сохранить порядок полей; HTML-экранирование
+	// сделало бы URI нечитаемым
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, field := range l.fields {
+		key, value := fields[field](l, e)
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(key); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.out.Write(buf.Bytes())
+	return err
+}
+
+// redactURI заменяет значения скрытых параметров запроса, сохраняя порядок остальных.
+// Имя сравнивается после декодирования, как его увидит обработчик: %74oken - это token.
+func (l *Logger) redactURI(uri string) string {
+	path, query, ok := strings.Cut(uri, "?")
+	if !ok || len(l.redact) == 0 {
+		return uri
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		decoded, err := url.QueryUnescape(name)
+		if err != nil {
+			decoded = name
+		}
+		if l.redact[strings.ToLower(decoded)] {
+			params[i] = name + "=" + redacted
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
@@ -0,0 +1,79 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	l, err := New(&out,
+		Fields(FieldMethod, FieldURI, FieldStatus, FieldBytesOut, FieldUserID, FieldRequestID, FieldError),
+		Redact("Token", "api_key"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(logger.Middleware(), l.Middleware())
+	e.GET("/users/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return echo.NewHTTPError(http.StatusNotFound, "not found")
+		}
+		c.SetRequest(c.Request().WithContext(auth.WithClaims(c.Request().Context(), auth.Claims{Subject: "alice"})))
+		return c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1?token=s3cr3t&name=ivan&API_KEY=k", nil)
+	req.Header.Set(logger.HeaderRequestID, "req-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/0", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %s", len(lines), out.String())
+	}
+	if want := `{"method":"GET","uri":"/users/1?token=REDACTED&name=ivan&API_KEY=REDACTED","status":200,"bytes_out":2,` +
+		`"user_id":"alice","request_id":"req-1","error":""}`; lines[0] != want {
+		t.Errorf("got  %s\nwant %s", lines[0], want)
+	}
+
+	var failed map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed["status"] != float64(http.StatusNotFound) || failed["error"] == "" || failed["user_id"] != "" {
+		t.Errorf("unexpected failed request line: %s", lines[1])
+	}
+}
+
+func TestUnknownField(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Fields(FieldMethod, "latency_human")); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestRedactEncodedNames(t *testing.T) {
+	l, err := New(&bytes.Buffer{}, Redact("token", "api_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := l.redactURI("/users?%74oken=s3cr3t&api%5Fkey=k&API%5FKEY=k&na%6De=ivan&bad%zz=1")
+	if want := "/users?%74oken=REDACTED&api%5Fkey=REDACTED&API%5FKEY=REDACTED&na%6De=ivan&bad%zz=1"; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
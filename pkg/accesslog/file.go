@@ -0,0 +1,26 @@
+package accesslog
+
+import (
+	"io"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// NewFile открывает файл журнала с ротацией: файл переименовывается, когда превышает maxSizeMB мегабайт,
+// старые файлы удаляются через maxAgeDays дней или сверх maxBackups штук и, если compress, сжимаются gzip.
+// Файл создается с правами 0600, каталог - при необходимости.
+func NewFile(path string, maxSizeMB, maxAgeDays, maxBackups int, compress bool) (io.WriteCloser, error) {
+	file := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSizeMB,
+		MaxAge:     maxAgeDays,
+		MaxBackups: maxBackups,
+		Compress:   compress,
+		LocalTime:  true,
+	}
+	// lumberjack открывает файл при первой записи, пустая запись проверяет, что файл доступен
+	if _, err := file.Write(nil); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,23 @@
+package accesslog
+
+import "strings"
+
+type Option func(*Logger)
+
+// Fields задает поля журнала и их порядок, пустой список оставляет DefaultFields
+func Fields(fields ...string) Option {
+	return func(l *Logger) {
+		if len(fields) > 0 {
+			l.fields = fields
+		}
+	}
+}
+
+// Redact задает параметры запроса, значения которых не пишутся в журнал. Имена сравниваются без учета регистра.
+func Redact(params ...string) Option {
+	return func(l *Logger) {
+		for _, param := range params {
+			l.redact[strings.ToLower(param)] = true
+		}
+	}
+}
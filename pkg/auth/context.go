@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type claimsKey struct{}
+
+// WithClaims кладет данные аутентифицированного клиента в контекст
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext возвращает данные клиента, ok=false для неаутентифицированного запроса
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
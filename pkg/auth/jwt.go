@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken - токен не разобран, подпись неверна или не прошли проверки claims
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken - срок действия токена истек
+	ErrExpiredToken = errors.New("token is expired")
+)
+
+// Claims - данные аутентифицированного клиента
+type Claims struct {
+	Subject string
+	Scopes  []string
+	Roles   []string
+	// APIKeyID - id ключа, если клиент прошел по API-ключу
+	APIKeyID int64
+}
+
+// HasScope проверяет, что токен выдан с указанной областью доступа
+func (c Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier проверяет JWT, подписанные HS256 общим секретом или RS256 ключом из JWKS
+type Verifier struct {
+	// secret - общий секрет из конфигурации, им проверяются токены HS256 без kid
+	secret   []byte
+	hmacKeys map[string][]byte
+	rsaKeys  map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+
+	now func() time.Time
+}
+
+func NewVerifier(opts ...Option) (*Verifier, error) {
+	v := &Verifier{
+		hmacKeys: make(map[string][]byte),
+		rsaKeys:  make(map[string]*rsa.PublicKey),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v.secret == nil && len(v.hmacKeys) == 0 && len(v.rsaKeys) == 0 {
+		return nil, errors.New("no keys configured for token verification")
+	}
+	return v, nil
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает его claims.
+// Токен без exp считается недействительным.
+func (v *Verifier) Verify(tokenString string) (Claims, error) {
+	parser := jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}}
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(tokenString, claims, v.key)
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return Claims{}, ErrExpiredToken
+		}
+		return Claims{}, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	now := v.now().Unix()
+	if !claims.VerifyExpiresAt(now, true) {
+		return Claims{}, fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if v.issuer != "" && !claims.VerifyIssuer(v.issuer, true) {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !claims.VerifyAudience(v.audience, true) {
+		return Claims{}, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return Claims{}, fmt.Errorf("%w: sub is required", ErrInvalidToken)
+	}
+
+	return Claims{Subject: subject, Scopes: scopes(claims), Roles: stringList(claims["roles"])}, nil
+}
+
+// key выбирает ключ проверки по алгоритму и kid из заголовка токена
+func (v *Verifier) key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if kid == "" && v.secret != nil {
+			return v.secret, nil
+		}
+		if key, ok := lookup(v.hmacKeys, kid); ok {
+			return key, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if key, ok := lookup(v.rsaKeys, kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s key for kid %q", token.Method.Alg(), kid)
+}
+
+// lookup ищет ключ по kid. Токен без kid подходит, только если ключ этого типа один.
+// Для HS256 токен без kid сначала проверяется общим секретом (см. key).
+func lookup[K any](keys map[string]K, kid string) (K, bool) {
+	if kid != "" {
+		key, ok := keys[kid]
+		return key, ok
+	}
+	var key K
+	if len(keys) != 1 {
+		return key, false
+	}
+	for _, k := range keys {
+		key = k
+	}
+	return key, true
+}
+
+// scopes читает области доступа из claim scope (строка через пробел) или scp (массив)
+func scopes(claims jwt.MapClaims) []string {
+	if s, ok := claims["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+	return stringList(claims["scp"])
+}
+
+// stringList читает claim-массив строк
+func stringList(claim interface{}) []string {
+	var result []string
+	if list, ok := claim.([]interface{}); ok {
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const testSecret = "secret"
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "alice",
+		"scope": "users:read users:write",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	v, err := NewVerifier(HMACSecret(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Valid token", func(t *testing.T) {
+		claims, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims(), ""))
+		if err != nil {
+			t.Fatalf("expected no error, but got %v", err)
+		}
+		if claims.Subject != "alice" || !claims.HasScope("users:write") {
+			t.Errorf("unexpected claims %+v", claims)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		token func() string
+		want  error
+	}{
+		{"Expired token", func() string {
+			c := validClaims()
+			c["exp"] = time.Now().Add(-time.Minute).Unix()
+			return sign(t, jwt.SigningMethodHS256, []byte(testSecret), c, "")
+		}, ErrExpiredToken},
+		{"Token without exp", func() string {
+			c := validClaims()
+			delete(c, "exp")
+			return sign(t, jwt.SigningMethodHS256, []byte(testSecret), c, "")
+		}, ErrInvalidToken},
+		{"Wrong secret", func() string {
+			return sign(t, jwt.SigningMethodHS256, []byte("other"), validClaims(), "")
+		}, ErrInvalidToken},
+		{"Unsigned token", func() string {
+			return sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims(), "")
+		}, ErrInvalidToken},
+		{"Malformed token", func() string { return "not.a.token" }, ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(tt.token()); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, wanted %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, _ := json.Marshal(set)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewVerifier(JWKSFile(path), Audience("user-service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := validClaims()
+	claims["aud"] = "user-service"
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, key, claims, "key-1")); err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, key, claims, "unknown")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for unknown kid, got %v", err)
+	}
+
+	claims["aud"] = "other"
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, key, claims, "key-1")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for wrong audience, got %v", err)
+	}
+}
+
+func TestVerifyHS256SecretWithJWKS(t *testing.T) {
+	set := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "oct", "kid": "hs-1", "k": base64.RawURLEncoding.EncodeToString([]byte("jwks-secret-1"))},
+			{"kty": "oct", "kid": "hs-2", "k": base64.RawURLEncoding.EncodeToString([]byte("jwks-secret-2"))},
+		},
+	}
+	data, _ := json.Marshal(set)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewVerifier(HMACSecret(testSecret), JWKSFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims(), "")); err != nil {
+		t.Errorf("token without kid: expected no error, but got %v", err)
+	}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte("jwks-secret-2"), validClaims(), "hs-2")); err != nil {
+		t.Errorf("token with kid: expected no error, but got %v", err)
+	}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(testSecret), validClaims(), "hs-1")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("secret must not verify a token with kid, got %v", err)
+	}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte("jwks-secret-1"), validClaims(), "")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("jwks key must not verify a token without kid, got %v", err)
+	}
+}
+
+func TestNewVerifierRequiresKeys(t *testing.T) {
+	if _, err := NewVerifier(HMACSecret("")); err == nil {
+		t.Error("expected error without keys")
+	}
+}
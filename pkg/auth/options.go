@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+type Option func(*Verifier) error
+
+// HMACSecret добавляет общий секрет для токенов HS256 без kid
+func HMACSecret(secret string) Option {
+	return func(v *Verifier) error {
+		if secret == "" {
+			return nil
+		}
+		v.secret = []byte(secret)
+		return nil
+	}
+}
+
+// JWKSFile загружает ключи из локального файла JWKS: RSA (kty=RSA) для RS256
+// и симметричные (kty=oct) для HS256
+func JWKSFile(path string) Option {
+	return func(v *Verifier) error {
+		if path == "" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read jwks file: %w", err)
+		}
+		return v.addJWKS(data)
+	}
+}
+
+// Issuer требует совпадения claim iss
+func Issuer(issuer string) Option {
+	return func(v *Verifier) error {
+		v.issuer = issuer
+		return nil
+	}
+}
+
+// Audience требует, чтобы claim aud содержал указанное значение
+func Audience(audience string) Option {
+	return func(v *Verifier) error {
+		v.audience = audience
+		return nil
+	}
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+func (v *Verifier) addJWKS(data []byte) error {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		switch key.Kty {
+		case "RSA":
+			pub, err := rsaPublicKey(key)
+			if err != nil {
+				return fmt.Errorf("jwks key %q: %w", key.Kid, err)
+			}
+			v.rsaKeys[key.Kid] = pub
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return fmt.Errorf("jwks key %q: %w", key.Kid, err)
+			}
+			v.hmacKeys[key.Kid] = secret
+		}
+	}
+	return nil
+}
+
+func rsaPublicKey(key jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+	if len(n) == 0 || len(e) == 0 {
+		return nil, errors.New("empty modulus or exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
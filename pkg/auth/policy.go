@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"sort"
+)
+
+// Разрешения на операции с пользователями и управление сервисом
+const (
+	PermUsersRead     = "users:read"
+	PermUsersWrite    = "users:write"
+	PermUsersDelete   = "users:delete"
+	PermDLQReplay     = "dlq:replay"
+	PermAPIKeysManage = "apikeys:manage"
+)
+
+// Policy сопоставляет роли разрешениям. Ролями клиента считаются scopes токена и роли API-ключа.
+type Policy struct {
+	roles map[string]map[string]struct{}
+}
+
+// NewPolicy создает политику из списка разрешений каждой роли
+func NewPolicy(roles map[string][]string) *Policy {
+	p := &Policy{
+		roles: make(map[string]map[string]struct{}, len(roles)),
+	}
+	for role, permissions := range roles {
+		set := make(map[string]struct{}, len(permissions))
+		for _, permission := range permissions {
+			set[permission] = struct{}{}
+		}
+		p.roles[role] = set
+	}
+	return p
+}
+
+// Permissions возвращает все разрешения клиента в порядке сортировки
+func (p *Policy) Permissions(claims Claims) []string {
+	set := make(map[string]struct{})
+	for _, role := range append(append([]string{}, claims.Scopes...), claims.Roles...) {
+		for permission := range p.roles[role] {
+			set[permission] = struct{}{}
+		}
+	}
+
+	permissions := make([]string, 0, len(set))
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+type permissionsKey struct{}
+
+// WithPermissions кладет в контекст разрешения клиента, после этого их проверяет Can
+func WithPermissions(ctx context.Context, permissions []string) context.Context {
+	return context.WithValue(ctx, permissionsKey{}, permissions)
+}
+
+// Can проверяет разрешение клиента из контекста. Если разрешения в контекст не клали
+// (проверка доступа выключена), разрешено все.
+func Can(ctx context.Context, permission string) bool {
+	permissions, ok := ctx.Value(permissionsKey{}).([]string)
+	if !ok {
+		return true
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPolicyPermissions(t *testing.T) {
+	policy := NewPolicy(map[string][]string{
+		"reader":   {PermUsersRead},
+		"operator": {PermUsersRead, PermUsersWrite},
+		"admin":    {PermUsersRead, PermUsersWrite, PermUsersDelete},
+	})
+
+	tests := []struct {
+		name   string
+		claims Claims
+		want   []string
+	}{
+		{"Scope as role", Claims{Scopes: []string{"reader"}}, []string{PermUsersRead}},
+		{"API key role", Claims{Roles: []string{"operator"}}, []string{PermUsersRead, PermUsersWrite}},
+		{"Roles are merged", Claims{Scopes: []string{"reader"}, Roles: []string{"admin"}}, []string{PermUsersDelete, PermUsersRead, PermUsersWrite}},
+		{"Unknown role", Claims{Scopes: []string{"openid"}}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Permissions(tt.claims); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCan(t *testing.T) {
+	if !Can(context.Background(), PermUsersDelete) {
+		t.Error("expected everything to be allowed without permissions in context")
+	}
+
+	ctx := WithPermissions(context.Background(), []string{PermUsersRead})
+	if !Can(ctx, PermUsersRead) || Can(ctx, PermUsersDelete) {
+		t.Error("unexpected permission check result")
+	}
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss возвращается, если ключа нет в кэше
+var ErrMiss = errors.New("cache miss")
+
+// Cache - хранилище значений кэша
+type Cache interface {
+	// Get возвращает значение по ключу или ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set сохраняет значение на ttl, ttl <= 0 - без срока жизни
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+	// Incr атомарно увеличивает числовое значение ключа и возвращает новое
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// Locker дает блокировку ключа между экземплярами сервиса
+type Locker interface {
+	// Lock пытается взять блокировку на ttl, ok=false - блокировку держит кто-то другой
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
+
+// LockingCache - хранилище, которое дает и блокировки ключей
+type LockingCache interface {
+	Cache
+	Locker
+}
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRetryPrimary = 10 * time.Second
+	// maxPendingKeys ограничивает число ключей, сброс которых повторяется в основном хранилище
+	maxPendingKeys = 10000
+)
+
+// Fallback обращается к основному хранилищу, а при его ошибке на время retry переключается на запасное,
+// так недоступный при запуске или во время работы Redis не остается выключенным до перезапуска.
+// Ключи, сброшенные (Del) или увеличенные (Incr) во время недоступности, повторяются в основном
+// хранилище при его возвращении, чтобы поколения списков и удаленные значения в нем не устарели.
+type Fallback struct {
+	primary   LockingCache
+	secondary LockingCache
+	retry     time.Duration
+	errors    uint64
+
+	mu         sync.Mutex
+	downUntil  time.Time
+	down       bool
+	recovering bool
+	deleted    map[string]bool
+	increased  map[string]bool
+
+	now func() time.Time
+}
+
+func NewFallback(primary, secondary LockingCache, retry time.Duration) *Fallback {
+	if retry <= 0 {
+		retry = defaultRetryPrimary
+	}
+	return &Fallback{
+		primary:   primary,
+		secondary: secondary,
+		retry:     retry,
+		deleted:   make(map[string]bool),
+		increased: make(map[string]bool),
+		now:       time.Now,
+	}
+}
+
+// MarkDown переключает на запасное хранилище до следующей попытки, например если Redis не ответил при запуске
+func (f *Fallback) MarkDown(err error) {
+	atomic.AddUint64(&f.errors, 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.down {
+		log.Warnf("Cache is unavailable, using fallback store for %s: %s", f.retry, err)
+	}
+	f.down = true
+	f.downUntil = f.now().Add(f.retry)
+}
+
+// Errors возвращает число ошибок основного хранилища с момента запуска
+func (f *Fallback) Errors() uint64 {
+	return atomic.LoadUint64(&f.errors)
+}
+
+func (f *Fallback) Get(ctx context.Context, key string) ([]byte, error) {
+	if f.usePrimary(ctx) {
+		value, err := f.primary.Get(ctx, key)
+		if err == ErrMiss || !f.failed(ctx, err) {
+			return value, err
+		}
+	}
+	return f.secondary.Get(ctx, key)
+}
+
+func (f *Fallback) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if f.usePrimary(ctx) {
+		err := f.primary.Set(ctx, key, value, ttl)
+		if !f.failed(ctx, err) {
+			return err
+		}
+	}
+	return f.secondary.Set(ctx, key, value, ttl)
+}
+
+func (f *Fallback) Del(ctx context.Context, keys ...string) error {
+	if f.usePrimary(ctx) {
+		err := f.primary.Del(ctx, keys...)
+		if !f.failed(ctx, err) {
+			return err
+		}
+	}
+	f.remember(f.deleted, keys...)
+	return f.secondary.Del(ctx, keys...)
+}
+
+func (f *Fallback) Incr(ctx context.Context, key string) (int64, error) {
+	if f.usePrimary(ctx) {
+		n, err := f.primary.Incr(ctx, key)
+		if !f.failed(ctx, err) {
+			return n, err
+		}
+	}
+	f.remember(f.increased, key)
+	return f.secondary.Incr(ctx, key)
+}
+
+func (f *Fallback) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	if f.usePrimary(ctx) {
+		unlock, ok, err := f.primary.Lock(ctx, key, ttl)
+		if !f.failed(ctx, err) {
+			return unlock, ok, err
+		}
+	}
+	return f.secondary.Lock(ctx, key, ttl)
+}
+
+// failed сообщает, что основное хранилище не ответило и запрос нужно повторить в запасном.
+// Отмена самого запроса не считается отказом хранилища.
+func (f *Fallback) failed(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	f.MarkDown(err)
+	return true
+}
+
+// usePrimary сообщает, можно ли обращаться к основному хранилищу. По истечении retry один вызов
+// повторяет в нем накопленные сбросы, остальные до конца повтора работают с запасным хранилищем.
+func (f *Fallback) usePrimary(ctx context.Context) bool {
+	f.mu.Lock()
+	if !f.down {
+		f.mu.Unlock()
+		return true
+	}
+	if f.recovering || f.now().Before(f.downUntil) {
+		f.mu.Unlock()
+		return false
+	}
+	f.recovering = true
+	f.mu.Unlock()
+
+	return f.recover(ctx)
+}
+
+// recover повторяет в основном хранилище сбросы, сделанные за время недоступности, и после успеха
+// переключается на него. Запросы к хранилищу идут без mu, чтобы не задерживать остальные вызовы;
+// ключи, сброшенные во время повтора, повторяются следующим проходом.
+func (f *Fallback) recover(ctx context.Context) bool {
+	for {
+		f.mu.Lock()
+		deleted, increased := f.deleted, f.increased
+		if len(deleted) == 0 && len(increased) == 0 {
+			f.down = false
+			f.recovering = false
+			f.mu.Unlock()
+			log.Info("Cache is available again")
+			return true
+		}
+		f.deleted = make(map[string]bool)
+		f.increased = make(map[string]bool)
+		f.mu.Unlock()
+
+		if err := f.replay(ctx, deleted, increased); err != nil {
+			atomic.AddUint64(&f.errors, 1)
+			f.mu.Lock()
+			// повторное увеличение поколения безопасно: оно лишь сбрасывает больше страниц
+			for key := range deleted {
+				f.deleted[key] = true
+			}
+			for key := range increased {
+				f.increased[key] = true
+			}
+			f.recovering = false
+			f.downUntil = f.now().Add(f.retry)
+			f.mu.Unlock()
+			return false
+		}
+	}
+}
+
+func (f *Fallback) replay(ctx context.Context, deleted, increased map[string]bool) error {
+	if len(deleted) > 0 {
+		keys := make([]string, 0, len(deleted))
+		for key := range deleted {
+			keys = append(keys, key)
+		}
+		if err := f.primary.Del(ctx, keys...); err != nil {
+			return err
+		}
+	}
+	for key := range increased {
+		if _, err := f.primary.Incr(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Fallback) remember(keys map[string]bool, add ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range add {
+		if len(keys) >= maxPendingKeys {
+			log.Warn("Too many cache keys changed while cache is unavailable, some may stay stale until their ttl")
+			return
+		}
+		keys[key] = true
+	}
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestFallbackRetriesPrimary(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	primary := NewRedisCache(redis.NewClient(&redis.Options{
+		Addr:        mr.Addr(),
+		MaxRetries:  -1,
+		DialTimeout: 100 * time.Millisecond,
+	}))
+	store := NewFallback(primary, NewMemory(10, 0), time.Minute)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	_ = mr.Set("gen", "5")
+	_ = mr.Set("users:v=5", "cached")
+	mr.Close()
+
+	// Пока Redis недоступен, поколение растет в запасном хранилище
+	if n, err := store.Incr(ctx, "gen"); err != nil || n != 1 {
+		t.Fatalf("got %d, %v from fallback Incr", n, err)
+	}
+	if err := store.Del(ctx, "users:v=5"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Errors() == 0 {
+		t.Error("expected errors to be counted")
+	}
+
+	if err := mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "gen"); err != nil {
+		t.Errorf("expected fallback store to be used until retry, got %v", err)
+	}
+	if !mr.Exists("users:v=5") {
+		t.Error("expected primary to stay untouched until retry")
+	}
+
+	now = now.Add(time.Minute)
+	value, err := store.Get(ctx, "gen")
+	if err != nil || string(value) != "6" {
+		t.Errorf("got %q, %v, wanted generation from Redis after replayed Incr", value, err)
+	}
+	if mr.Exists("users:v=5") {
+		t.Error("expected key deleted during outage to be deleted in Redis")
+	}
+}
+
+// blockingIncr - основное хранилище, в котором Incr ждет, пока тест его не отпустит
+type blockingIncr struct {
+	*Memory
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingIncr) Incr(ctx context.Context, key string) (int64, error) {
+	close(b.started)
+	<-b.release
+	return b.Memory.Incr(ctx, key)
+}
+
+func TestFallbackReplayDoesNotBlockOtherCalls(t *testing.T) {
+	ctx := context.Background()
+	primary := &blockingIncr{Memory: NewMemory(10, 0), started: make(chan struct{}), release: make(chan struct{})}
+	store := NewFallback(primary, NewMemory(10, 0), time.Minute)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.MarkDown(errors.New("redis is down"))
+	_, _ = store.Incr(ctx, "gen")
+	_ = store.secondary.Set(ctx, "key", []byte("local"), time.Minute)
+	now = now.Add(time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = store.Get(ctx, "gen")
+	}()
+	<-primary.started
+
+	// пока повтор ждет основное хранилище, остальные вызовы обслуживает запасное
+	value, err := store.Get(ctx, "key")
+	if err != nil || string(value) != "local" {
+		t.Errorf("got %q, %v, wanted value from fallback store during replay", value, err)
+	}
+
+	close(primary.release)
+	<-done
+	if value, err := store.Get(ctx, "gen"); err != nil || string(value) != "1" {
+		t.Errorf("got %q, %v, wanted replayed generation from primary", value, err)
+	}
+}
@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"golang.org/x/sync/singleflight"
+	"sync/atomic"
+	"time"
+	"user-service/pkg/logger"
+)
+
+const (
+	defaultStaleWindow  = 30 * time.Second
+	defaultLockTTL      = 5 * time.Second
+	defaultLockWait     = 2 * time.Second
+	defaultFetchTimeout = 10 * time.Second
+	lockPollInterval    = 50 * time.Millisecond
+
+	// headerSize - длина заголовка значения: момент, до которого значение свежее (unix nano)
+	headerSize = 8
+)
+
+// FetchFunc загружает значение из источника (обычно из базы) при промахе кэша
+type FetchFunc func(ctx context.Context) ([]byte, error)
+
+// Loader - кэш со сквозным чтением, общий для всех читающих эндпоинтов
+type Loader interface {
+	// Load возвращает значение по ключу, при промахе загружает его через fetch и сохраняет на ttl
+	Load(ctx context.Context, key string, ttl time.Duration, fetch FetchFunc) ([]byte, error)
+}
+
+// ReadThrough защищает источник от лавины запросов при истечении ключа:
+//   - внутри процесса одновременные промахи по одному ключу объединяются через singleflight;
+//   - между экземплярами ключ загружает только тот, кто взял блокировку, остальные ждут значение;
+//   - в течение окна stale-while-revalidate после истечения ttl отдается старое значение,
+//     а обновляет его в фоне один запрос.
+type ReadThrough struct {
+	// счетчики идут первыми, чтобы быть выровненными для atomic на 32-битных платформах
+	hits   uint64
+	stale  uint64
+	misses uint64
+
+	store  Cache
+	locker Locker
+	group  singleflight.Group
+
+	staleWindow  time.Duration
+	lockTTL      time.Duration
+	lockWait     time.Duration
+	fetchTimeout time.Duration
+
+	now func() time.Time
+}
+
+// NewReadThrough создает загрузчик поверх хранилища store. Блокировки между экземплярами
+// берутся через locker, при locker == nil значение загружает каждый экземпляр сам.
+func NewReadThrough(store Cache, locker Locker, opts ...Option) *ReadThrough {
+	l := &ReadThrough{
+		store:        store,
+		locker:       locker,
+		staleWindow:  defaultStaleWindow,
+		lockTTL:      defaultLockTTL,
+		lockWait:     defaultLockWait,
+		fetchTimeout: defaultFetchTimeout,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+func (l *ReadThrough) Load(ctx context.Context, key string, ttl time.Duration, fetch FetchFunc) ([]byte, error) {
+	raw, err := l.store.Get(ctx, key)
+	if err == nil && len(raw) >= headerSize {
+		freshUntil := time.Unix(0, int64(binary.BigEndian.Uint64(raw)))
+		value := raw[headerSize:]
+		if l.now().After(freshUntil) {
+			// Значение устарело: отдаем его, а обновление запускаем в фоне
+			atomic.AddUint64(&l.stale, 1)
+			l.group.DoChan("refresh:"+key, func() (interface{}, error) {
+				return l.refresh(ctx, key, ttl, fetch)
+			})
+		} else {
+			atomic.AddUint64(&l.hits, 1)
+		}
+		return value, nil
+	}
+	atomic.AddUint64(&l.misses, 1)
+	if err != nil && !errors.Is(err, ErrMiss) {
+		logger.FromContext(ctx).Warn("Cache read failed, loading from source:", err)
+	}
+
+	v, err, _ := l.group.Do(key, func() (interface{}, error) {
+		return l.fill(ctx, key, ttl, fetch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	value, _ := v.([]byte)
+	return value, nil
+}
+
+// LoaderStats - счетчики обращений к загрузчику: Stale - попадания в устаревшее значение,
+// Misses - обращения, при которых значение загружалось из источника
+type LoaderStats struct {
+	Hits   uint64 `json:"hits"`
+	Stale  uint64 `json:"stale"`
+	Misses uint64 `json:"misses"`
+}
+
+// Stats возвращает текущие значения счетчиков
+func (l *ReadThrough) Stats() LoaderStats {
+	return LoaderStats{
+		Hits:   atomic.LoadUint64(&l.hits),
+		Stale:  atomic.LoadUint64(&l.stale),
+		Misses: atomic.LoadUint64(&l.misses),
+	}
+}
+
+// fill загружает отсутствующее значение. Если блокировку держит другой экземпляр,
+// сначала ждем, пока он положит значение в кэш. Загрузка общая для всех ждущих запросов,
+// поэтому не отменяется вместе с ctx первого из них, но продолжает его трассу и поля лога.
+func (l *ReadThrough) fill(ctx context.Context, key string, ttl time.Duration, fetch FetchFunc) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(logger.Detach(ctx), l.fetchTimeout)
+	defer cancel()
+
+	unlock, locked := l.lock(ctx, key)
+	if !locked {
+		if value, ok := l.wait(ctx, key); ok {
+			return value, nil
+		}
+	}
+	defer unlock()
+
+	return l.fetchAndStore(ctx, key, ttl, fetch)
+}
+
+// refresh в фоне обновляет устаревшее значение, если его еще не обновляет другой экземпляр
+func (l *ReadThrough) refresh(ctx context.Context, key string, ttl time.Duration, fetch FetchFunc) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(logger.Detach(ctx), l.fetchTimeout)
+	defer cancel()
+
+	unlock, locked := l.lock(ctx, key)
+	if !locked {
+		return nil, nil
+	}
+	defer unlock()
+
+	value, err := l.fetchAndStore(ctx, key, ttl, fetch)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to refresh stale cache value:", err)
+	}
+	return value, err
+}
+
+func (l *ReadThrough) fetchAndStore(ctx context.Context, key string, ttl time.Duration, fetch FetchFunc) ([]byte, error) {
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, headerSize+len(value))
+	binary.BigEndian.PutUint64(raw, uint64(l.now().Add(ttl).UnixNano()))
+	copy(raw[headerSize:], value)
+	if err := l.store.Set(ctx, key, raw, ttl+l.staleWindow); err != nil {
+		logger.FromContext(ctx).Warn("Failed to store value in cache:", err)
+	}
+	return value, nil
+}
+
+// lock берет блокировку загрузки ключа. Если блокировки не настроены или недоступны,
+// считаем, что блокировка взята, чтобы не ждать зря.
+func (l *ReadThrough) lock(ctx context.Context, key string) (func(), bool) {
+	if l.locker == nil {
+		return func() {}, true
+	}
+	unlock, ok, err := l.locker.Lock(ctx, key, l.lockTTL)
+	if err != nil {
+		return func() {}, true
+	}
+	return unlock, ok
+}
+
+// wait ждет, пока значение загрузит другой экземпляр, не дольше lockWait
+func (l *ReadThrough) wait(ctx context.Context, key string) ([]byte, bool) {
+	deadline := time.NewTimer(l.lockWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-deadline.C:
+			return nil, false
+		case <-ticker.C:
+			raw, err := l.store.Get(ctx, key)
+			if err == nil && len(raw) >= headerSize {
+				return raw[headerSize:], true
+			}
+		}
+	}
+}
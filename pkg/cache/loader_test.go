@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"user-service/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestLoader(t *testing.T, opts ...Option) (*ReadThrough, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rc := NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	return NewReadThrough(rc, rc, opts...), mr
+}
+
+func TestLoadCoalescesConcurrentMisses(t *testing.T) {
+	loader, _ := newTestLoader(t)
+
+	var calls int32
+	fetch := func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return []byte("value"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := loader.Load(context.Background(), "key", time.Minute, fetch)
+			if err != nil || string(value) != "value" {
+				t.Errorf("got %q, %v", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fetch called %d times, wanted 1", got)
+	}
+
+	// повторное чтение берется из кэша
+	_, _ = loader.Load(context.Background(), "key", time.Minute, fetch)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fetch called %d times after cache hit, wanted 1", got)
+	}
+}
+
+func TestLoadServesStaleWhileRevalidating(t *testing.T) {
+	loader, _ := newTestLoader(t, StaleWhileRevalidate(time.Minute))
+	now := time.Now()
+	loader.now = func() time.Time { return now }
+
+	refreshed := make(chan struct{})
+	version := "v1"
+	fetch := func(ctx context.Context) ([]byte, error) {
+		if version == "v2" {
+			defer close(refreshed)
+		}
+		return []byte(version), nil
+	}
+
+	if _, err := loader.Load(context.Background(), "key", time.Second, fetch); err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+
+	// ttl истек, но окно stale-while-revalidate еще нет: отдается старое значение, а новое грузится в фоне
+	now = now.Add(2 * time.Second)
+	version = "v2"
+	value, err := loader.Load(context.Background(), "key", time.Second, fetch)
+	if err != nil || string(value) != "v1" {
+		t.Fatalf("got %q, %v, wanted stale v1", value, err)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale value was not refreshed")
+	}
+	// ждем, пока фоновое обновление запишет значение
+	time.Sleep(50 * time.Millisecond)
+
+	value, _ = loader.Load(context.Background(), "key", time.Second, fetch)
+	if string(value) != "v2" {
+		t.Errorf("got %q, wanted refreshed v2", value)
+	}
+
+	if got, want := loader.Stats(), (LoaderStats{Hits: 1, Stale: 1, Misses: 1}); got != want {
+		t.Errorf("got stats %+v, wanted %+v", got, want)
+	}
+}
+
+func TestLoadWaitsForOtherInstance(t *testing.T) {
+	loader, mr := newTestLoader(t, LockWait(time.Second))
+
+	// блокировку держит другой экземпляр, который через 100мс кладет значение
+	if err := mr.Set(lockKey("key"), "other"); err != nil {
+		t.Fatal(err)
+	}
+	rc := NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	other := NewReadThrough(rc, rc)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = other.fetchAndStore(context.Background(), "key", time.Minute, func(ctx context.Context) ([]byte, error) {
+			return []byte("from other"), nil
+		})
+	}()
+
+	value, err := loader.Load(context.Background(), "key", time.Minute, func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("must not be called")
+	})
+	if err != nil || string(value) != "from other" {
+		t.Errorf("got %q, %v, wanted value from other instance", value, err)
+	}
+}
+
+func TestLoadDoesNotCacheErrors(t *testing.T) {
+	loader, mr := newTestLoader(t)
+
+	_, err := loader.Load(context.Background(), "key", time.Minute, func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("DB error")
+	})
+	if err == nil {
+		t.Fatal("expected error, but got none")
+	}
+	if mr.Exists("key") {
+		t.Error("error result must not be cached")
+	}
+	if mr.Exists(lockKey("key")) {
+		t.Error("lock must be released")
+	}
+}
+
+func TestLoadFetchKeepsRequestCorrelation(t *testing.T) {
+	loader, _ := newTestLoader(t)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}})
+	ctx := logger.WithRequestID(trace.ContextWithSpanContext(context.Background(), sc), "req-1")
+
+	var fetchCtx context.Context
+	_, err := loader.Load(ctx, "key", time.Minute, func(ctx context.Context) ([]byte, error) {
+		fetchCtx = ctx
+		return []byte("value"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.RequestID(fetchCtx) != "req-1" || !trace.SpanContextFromContext(fetchCtx).Equal(sc) {
+		t.Errorf("fetch lost request correlation: %v", logger.FromContext(fetchCtx).Data)
+	}
+}
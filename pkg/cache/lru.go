@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LRU - ограниченный по числу записей и суммарному размеру кэш в памяти процесса.
+// При переполнении вытесняются давно не читанные записи, истекшие записи удаляются при чтении.
+type LRU struct {
+	mu         sync.Mutex
+	ll         *list.List
+	items      map[string]*list.Element
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	evictions  uint64
+
+	now func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU создает кэш, maxEntries или maxBytes <= 0 снимают соответствующее ограничение
+func NewLRU(maxEntries int, maxBytes int64) *LRU {
+	return &LRU{
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		now:        time.Now,
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && c.now().After(entry.expiresAt) {
+		c.remove(elem)
+		return nil, ErrMiss
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value, ttl)
+	return nil
+}
+
+func (c *LRU) Del(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+func (c *LRU) Incr(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	var ttl time.Duration
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		var err error
+		n, err = strconv.ParseInt(string(entry.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if !entry.expiresAt.IsZero() {
+			ttl = entry.expiresAt.Sub(c.now())
+		}
+	}
+	n++
+	c.set(key, []byte(strconv.FormatInt(n, 10)), ttl)
+	return n, nil
+}
+
+// Purge удаляет все записи
+func (c *LRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+// Len возвращает число записей
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Bytes возвращает суммарный размер значений
+func (c *LRU) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// Evictions возвращает число записей, вытесненных из-за ограничений размера
+func (c *LRU) Evictions() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.evictions
+}
+
+func (c *LRU) set(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		c.bytes += int64(len(value) - len(entry.value))
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+	} else {
+		c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+		c.bytes += int64(len(value))
+	}
+
+	for c.ll.Len() > 0 && ((c.maxEntries > 0 && c.ll.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.remove(c.ll.Back())
+		c.evictions++
+	}
+}
+
+func (c *LRU) remove(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	c.ll.Remove(elem)
+	delete(c.items, entry.key)
+	c.bytes -= int64(len(entry.value))
+}
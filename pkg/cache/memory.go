@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory - кэш и блокировки в памяти процесса. Подходит для одного экземпляра сервиса
+// или как запасной вариант, когда Redis недоступен: другие экземпляры его изменений не видят.
+// Счетчики Incr хранятся отдельно от LRU и не вытесняются: по счетчику, например поколению списков,
+// версионируются другие ключи, и его потеря вернула бы к жизни устаревшие значения.
+type Memory struct {
+	*LRU
+
+	mu       sync.Mutex
+	locks    map[string]time.Time
+	counters map[string]int64
+}
+
+func NewMemory(maxEntries int, maxBytes int64) *Memory {
+	return &Memory{
+		LRU:      NewLRU(maxEntries, maxBytes),
+		locks:    make(map[string]time.Time),
+		counters: make(map[string]int64),
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	n, ok := m.counters[key]
+	m.mu.Unlock()
+	if ok {
+		return []byte(strconv.FormatInt(n, 10)), nil
+	}
+	return m.LRU.Get(ctx, key)
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	delete(m.counters, key)
+	m.mu.Unlock()
+	return m.LRU.Set(ctx, key, value, ttl)
+}
+
+func (m *Memory) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	for _, key := range keys {
+		delete(m.counters, key)
+	}
+	m.mu.Unlock()
+	return m.LRU.Del(ctx, keys...)
+}
+
+// Incr увеличивает счетчик вне LRU. Значение, записанное ранее через Set, переносится в счетчик.
+func (m *Memory) Incr(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.counters[key]
+	if !ok {
+		value, err := m.LRU.Get(ctx, key)
+		if err == nil {
+			if n, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+				return 0, err
+			}
+		}
+		if err := m.LRU.Del(ctx, key); err != nil {
+			return 0, err
+		}
+	}
+	n++
+	m.counters[key] = n
+	return n, nil
+}
+
+// Lock берет блокировку внутри процесса, по истечении ttl она снимается сама
+func (m *Memory) Lock(_ context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if until, ok := m.locks[key]; ok && now.Before(until) {
+		return func() {}, false, nil
+	}
+	until := now.Add(ttl)
+	m.locks[key] = until
+
+	unlock := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// блокировка могла истечь и достаться другому
+		if m.locks[key] == until {
+			delete(m.locks, key)
+		}
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLock(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10, 0)
+
+	unlock, ok, _ := m.Lock(ctx, "key", time.Minute)
+	if !ok {
+		t.Fatal("expected lock to be acquired")
+	}
+	if _, ok, _ := m.Lock(ctx, "key", time.Minute); ok {
+		t.Error("expected lock to be held")
+	}
+	unlock()
+	if _, ok, _ := m.Lock(ctx, "key", time.Minute); !ok {
+		t.Error("expected lock to be released")
+	}
+}
+
+func TestMemoryKeepsCountersOnEviction(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2, 0)
+
+	if _, err := m.Incr(ctx, "gen"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		_ = m.Set(ctx, key, []byte(key), time.Minute)
+	}
+
+	value, err := m.Get(ctx, "gen")
+	if err != nil || string(value) != "1" {
+		t.Errorf("got %q, %v, wanted counter to survive eviction", value, err)
+	}
+	if n, _ := m.Incr(ctx, "gen"); n != 2 {
+		t.Errorf("got %d after second Incr, wanted 2", n)
+	}
+}
@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Noop - отключенный кэш: ничего не хранит, каждое чтение - промах
+type Noop struct{}
+
+func (Noop) Get(context.Context, string) ([]byte, error) {
+	return nil, ErrMiss
+}
+
+func (Noop) Set(context.Context, string, []byte, time.Duration) error {
+	return nil
+}
+
+func (Noop) Del(context.Context, ...string) error {
+	return nil
+}
+
+func (Noop) Incr(context.Context, string) (int64, error) {
+	return 0, nil
+}
+
+func (Noop) Lock(context.Context, string, time.Duration) (func(), bool, error) {
+	return func() {}, true, nil
+}
@@ -0,0 +1,34 @@
+package cache
+
+import "time"
+
+type Option func(*ReadThrough)
+
+// StaleWhileRevalidate задает окно, в течение которого после истечения ttl отдается
+// устаревшее значение, пока один запрос обновляет его в фоне
+func StaleWhileRevalidate(window time.Duration) Option {
+	return func(l *ReadThrough) {
+		l.staleWindow = window
+	}
+}
+
+// LockTTL задает время жизни блокировки загрузки ключа между экземплярами сервиса
+func LockTTL(ttl time.Duration) Option {
+	return func(l *ReadThrough) {
+		l.lockTTL = ttl
+	}
+}
+
+// LockWait задает, сколько ждать значения, пока его загружает другой экземпляр
+func LockWait(wait time.Duration) Option {
+	return func(l *ReadThrough) {
+		l.lockWait = wait
+	}
+}
+
+// FetchTimeout ограничивает время загрузки значения из источника
+func FetchTimeout(timeout time.Duration) Option {
+	return func(l *ReadThrough) {
+		l.fetchTimeout = timeout
+	}
+}
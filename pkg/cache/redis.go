@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// unlockScript снимает блокировку, только если ее держит этот же владелец
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisCache - кэш и блокировки в Redis
+type RedisCache struct {
+	client *redis.Client
+}
+
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{
+		client: client,
+	}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *RedisCache) Del(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
+}
+
+func (c *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
+	return c.client.Incr(ctx, key).Result()
+}
+
+// Lock берет блокировку SET NX с уникальным токеном, снять ее может только владелец токена
+func (c *RedisCache) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	token := newToken()
+	ok, err := c.client.SetNX(ctx, lockKey(key), token, ttl).Result()
+	if err != nil || !ok {
+		return func() {}, false, err
+	}
+
+	unlock := func() {
+		err := unlockScript.Run(context.Background(), c.client, []string{lockKey(key)}, token).Err()
+		if err != nil && err != redis.Nil {
+			log.Warn("Failed to release cache lock:", err)
+		}
+	}
+	return unlock, true, nil
+}
+
+func lockKey(key string) string {
+	return "lock:" + key
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultInvalidationChannel = "cache:invalidate"
+	defaultLocalTTL            = 5 * time.Second
+	subscribeTimeout           = 2 * time.Second
+)
+
+// invalidation - сообщение об изменении ключей, которое рассылается остальным экземплярам
+type invalidation struct {
+	From string   `json:"from"`
+	Keys []string `json:"keys"`
+}
+
+// Stats - счетчики попаданий и промахов по уровням кэша
+type Stats struct {
+	LocalHits     uint64 `json:"local_hits"`
+	LocalMisses   uint64 `json:"local_misses"`
+	RemoteHits    uint64 `json:"remote_hits"`
+	RemoteMisses  uint64 `json:"remote_misses"`
+	Invalidations uint64 `json:"invalidations"`
+	Evictions     uint64 `json:"evictions"`
+	LocalEntries  int    `json:"local_entries"`
+	LocalBytes    int64  `json:"local_bytes"`
+}
+
+// Tiered - двухуровневый кэш: локальный LRU перед Redis. Чтение сначала идет в память процесса,
+// при промахе - в Redis. Запись и удаление меняют оба уровня и рассылают через pub/sub
+// сообщение, по которому остальные экземпляры сбрасывают свои локальные копии ключей.
+type Tiered struct {
+	// счетчики идут первыми, чтобы быть выровненными для atomic на 32-битных платформах
+	localHits     uint64
+	localMisses   uint64
+	remoteHits    uint64
+	remoteMisses  uint64
+	invalidations uint64
+
+	local      *LRU
+	remote     *RedisCache
+	client     *redis.Client
+	pubsub     *redis.PubSub
+	done       chan struct{}
+	instanceID string
+
+	channel  string
+	localTTL time.Duration
+}
+
+type TieredOption func(*Tiered)
+
+// LocalTTL ограничивает время жизни значения в локальном уровне. Это верхняя граница
+// расхождения с Redis, если сообщение об инвалидации потерялось.
+func LocalTTL(ttl time.Duration) TieredOption {
+	return func(t *Tiered) {
+		t.localTTL = ttl
+	}
+}
+
+// InvalidationChannel задает канал Redis для сообщений об инвалидации
+func InvalidationChannel(channel string) TieredOption {
+	return func(t *Tiered) {
+		t.channel = channel
+	}
+}
+
+// NewTiered создает двухуровневый кэш и подписывается на сообщения об инвалидации.
+// Подписку нужно закрыть через Close.
+func NewTiered(client *redis.Client, local *LRU, opts ...TieredOption) *Tiered {
+	t := &Tiered{
+		local:      local,
+		remote:     NewRedisCache(client),
+		client:     client,
+		done:       make(chan struct{}),
+		instanceID: newToken(),
+		channel:    defaultInvalidationChannel,
+		localTTL:   defaultLocalTTL,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	t.pubsub = client.Subscribe(context.Background(), t.channel)
+	ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+	defer cancel()
+	if _, err := t.pubsub.Receive(ctx); err != nil {
+		log.Warn("Failed to subscribe to cache invalidations, will retry in background:", err)
+	}
+	go t.listen()
+
+	return t
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := t.local.Get(ctx, key)
+	if err == nil {
+		atomic.AddUint64(&t.localHits, 1)
+		return value, nil
+	}
+	atomic.AddUint64(&t.localMisses, 1)
+
+	value, err = t.remote.Get(ctx, key)
+	if errors.Is(err, ErrMiss) {
+		atomic.AddUint64(&t.remoteMisses, 1)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddUint64(&t.remoteHits, 1)
+
+	_ = t.local.Set(ctx, key, value, t.localTTL)
+	return value, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := t.remote.Set(ctx, key, value, ttl); err != nil {
+		_ = t.local.Del(ctx, key)
+		return err
+	}
+
+	localTTL := t.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	_ = t.local.Set(ctx, key, value, localTTL)
+	t.publish(ctx, key)
+	return nil
+}
+
+func (t *Tiered) Del(ctx context.Context, keys ...string) error {
+	_ = t.local.Del(ctx, keys...)
+	err := t.remote.Del(ctx, keys...)
+	t.publish(ctx, keys...)
+	return err
+}
+
+func (t *Tiered) Incr(ctx context.Context, key string) (int64, error) {
+	_ = t.local.Del(ctx, key)
+	n, err := t.remote.Incr(ctx, key)
+	t.publish(ctx, key)
+	return n, err
+}
+
+// Lock берет блокировку в Redis, локальный уровень в ней не участвует
+func (t *Tiered) Lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	return t.remote.Lock(ctx, key, ttl)
+}
+
+// Stats возвращает текущие значения счетчиков
+func (t *Tiered) Stats() Stats {
+	return Stats{
+		LocalHits:     atomic.LoadUint64(&t.localHits),
+		LocalMisses:   atomic.LoadUint64(&t.localMisses),
+		RemoteHits:    atomic.LoadUint64(&t.remoteHits),
+		RemoteMisses:  atomic.LoadUint64(&t.remoteMisses),
+		Invalidations: atomic.LoadUint64(&t.invalidations),
+		Evictions:     t.local.Evictions(),
+		LocalEntries:  t.local.Len(),
+		LocalBytes:    t.local.Bytes(),
+	}
+}
+
+// Close отписывается от сообщений об инвалидации
+func (t *Tiered) Close() error {
+	err := t.pubsub.Close()
+	<-t.done
+	return err
+}
+
+func (t *Tiered) publish(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	msg, err := json.Marshal(invalidation{From: t.instanceID, Keys: keys})
+	if err != nil {
+		log.Error("Failed to encode cache invalidation:", err)
+		return
+	}
+	if err := t.client.Publish(ctx, t.channel, msg).Err(); err != nil {
+		log.Warn("Failed to publish cache invalidation:", err)
+	}
+}
+
+// listen сбрасывает локальные копии ключей, измененных другими экземплярами
+func (t *Tiered) listen() {
+	defer close(t.done)
+
+	for m := range t.pubsub.Channel() {
+		var msg invalidation
+		if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+			log.Warn("Invalid cache invalidation message:", err)
+			continue
+		}
+		if msg.From == t.instanceID {
+			continue
+		}
+		_ = t.local.Del(context.Background(), msg.Keys...)
+		atomic.AddUint64(&t.invalidations, 1)
+	}
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestTiered(t *testing.T, mr *miniredis.Miniredis, opts ...TieredOption) *Tiered {
+	t.Helper()
+	tc := NewTiered(redis.NewClient(&redis.Options{Addr: mr.Addr()}), NewLRU(100, 0), opts...)
+	t.Cleanup(func() { _ = tc.Close() })
+	return tc
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2, 0)
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), 0)
+	_, _ = c.Get(ctx, "a")
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expected b to be evicted, got %v", err)
+	}
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Errorf("expected a to stay, got %v", err)
+	}
+	if c.Evictions() != 1 {
+		t.Errorf("got %d evictions, wanted 1", c.Evictions())
+	}
+}
+
+func TestLRURespectsMaxBytesAndTTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(0, 10)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	_ = c.Set(ctx, "a", []byte("12345"), time.Second)
+	_ = c.Set(ctx, "b", []byte("123456"), 0)
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expected a to be evicted by size, got %v", err)
+	}
+	if c.Bytes() != 6 {
+		t.Errorf("got %d bytes, wanted 6", c.Bytes())
+	}
+
+	_ = c.Set(ctx, "c", []byte("1"), time.Second)
+	now = now.Add(2 * time.Second)
+	if _, err := c.Get(ctx, "c"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expected c to expire, got %v", err)
+	}
+	if _, err := c.Get(ctx, "b"); err != nil {
+		t.Errorf("expected b without ttl to stay, got %v", err)
+	}
+}
+
+func TestTieredServesFromLocalTier(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	tc := newTestTiered(t, mr, LocalTTL(time.Minute))
+
+	if err := mr.Set("key", "value"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		value, err := tc.Get(ctx, "key")
+		if err != nil || string(value) != "value" {
+			t.Fatalf("got %q, %v", value, err)
+		}
+	}
+	if _, err := tc.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expected ErrMiss, got %v", err)
+	}
+
+	stats := tc.Stats()
+	if stats.RemoteHits != 1 || stats.LocalHits != 2 || stats.RemoteMisses != 1 || stats.LocalMisses != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestTieredInvalidatesOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	first := newTestTiered(t, mr, LocalTTL(time.Minute))
+	second := newTestTiered(t, mr, LocalTTL(time.Minute))
+
+	if err := first.Set(ctx, "key", []byte("v1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := second.Get(ctx, "key"); string(value) != "v1" {
+		t.Fatalf("got %q, wanted v1", value)
+	}
+
+	if err := first.Set(ctx, "key", []byte("v2"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, _ := second.Get(ctx, "key")
+		if string(value) == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("second instance still serves %q", value)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := second.Stats().Invalidations; got == 0 {
+		t.Error("expected invalidation to be counted")
+	}
+	// собственные сообщения не сбрасывают локальный уровень
+	if got := first.Stats().Invalidations; got != 0 {
+		t.Errorf("got %d own invalidations, wanted 0", got)
+	}
+}
+
+func TestTieredIncrDropsLocalCopy(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	tc := newTestTiered(t, mr)
+
+	if _, err := tc.Incr(ctx, "gen"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := tc.Get(ctx, "gen"); string(value) != "1" {
+		t.Fatalf("got %q, wanted 1", value)
+	}
+	if _, err := tc.Incr(ctx, "gen"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := tc.Get(ctx, "gen"); string(value) != "2" {
+		t.Errorf("got %q, wanted 2", value)
+	}
+}
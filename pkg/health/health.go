@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// Статусы проверок и готовности
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusDegraded     = "degraded"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check проверяет одну зависимость, ошибка означает, что зависимость недоступна
+type Check func(ctx context.Context) error
+
+type check struct {
+	name     string
+	critical bool
+	fn       Check
+}
+
+// Checker отвечает на пробы Kubernetes: /healthz - процесс жив, /readyz - зависимости доступны
+// и сервис не останавливается
+type Checker struct {
+	checks   []check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// Result - итог одной проверки
+type Result struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	// Duration - длительность проверки в миллисекундах
+	Duration int64 `json:"duration_ms"`
+}
+
+// Report - итог всех проверок. Status - fail, если не прошла хотя бы одна критичная проверка,
+// degraded - если не прошли только некритичные, shutting_down - во время остановки.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+func New(opts ...Option) *Checker {
+	c := &Checker{timeout: defaultTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Add добавляет проверку. Не прошедшая критичная проверка делает сервис неготовым,
+// некритичная только отмечается в отчете: без такой зависимости сервис работает с ограничениями.
+func (c *Checker) Add(name string, critical bool, fn Check) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Shutdown переводит готовность в состояние shutting_down, чтобы балансировщик перестал
+// присылать запросы до остановки сервера
+func (c *Checker) Shutdown() {
+	c.draining.Store(true)
+}
+
+// Ready выполняет все проверки параллельно, каждую не дольше timeout
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			result := c.run(ctx, ch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[ch.name] = result
+			if result.Status == StatusOK {
+				return
+			}
+			if ch.critical {
+				report.Status = StatusFail
+			} else if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}(ch)
+	}
+	wg.Wait()
+
+	if c.draining.Load() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, ch check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.fn(ctx)
+	result := Result{Status: StatusOK, Critical: ch.critical, Duration: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LiveHandler отвечает 200, пока процесс способен обрабатывать запросы. Зависимости не проверяются,
+// чтобы недоступная база не приводила к перезапуску всех экземпляров.
+func (c *Checker) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadyHandler отвечает 200 с отчетом по проверкам, если сервис готов, иначе 503
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+		status := http.StatusOK
+		if report.Status == StatusFail || report.Status == StatusShuttingDown {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ready(t *testing.T, c *Checker) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, report
+}
+
+func ok(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("connection refused") }
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		critical   Check
+		optional   Check
+		wantCode   int
+		wantStatus string
+	}{
+		{"all ok", ok, ok, http.StatusOK, StatusOK},
+		{"optional failed", ok, fail, http.StatusOK, StatusDegraded},
+		{"critical failed", fail, ok, http.StatusServiceUnavailable, StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			c.Add("postgres", true, tt.critical)
+			c.Add("redis", false, tt.optional)
+
+			code, report := ready(t, c)
+			if code != tt.wantCode || report.Status != tt.wantStatus {
+				t.Fatalf("got %d %s, want %d %s", code, report.Status, tt.wantCode, tt.wantStatus)
+			}
+			if len(report.Checks) != 2 || !report.Checks["postgres"].Critical || report.Checks["redis"].Critical {
+				t.Fatalf("unexpected checks: %+v", report.Checks)
+			}
+		})
+	}
+}
+
+func TestReadyTimeout(t *testing.T) {
+	c := New(Timeout(10 * time.Millisecond))
+	c.Add("kafka", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, report := ready(t, c)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if result := report.Checks["kafka"]; result.Status != StatusFail || result.Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	c := New()
+	c.Add("postgres", true, ok)
+	c.Shutdown()
+
+	code, report := ready(t, c)
+	if code != http.StatusServiceUnavailable || report.Status != StatusShuttingDown {
+		t.Fatalf("got %d %s, want %d %s", code, report.Status, http.StatusServiceUnavailable, StatusShuttingDown)
+	}
+
+	rec := httptest.NewRecorder()
+	c.LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("liveness got %d, want %d", rec.Code, http.StatusOK)
+	}
+}
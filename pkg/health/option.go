@@ -0,0 +1,12 @@
+package health
+
+import "time"
+
+type Option func(*Checker)
+
+// Timeout ограничивает время каждой проверки
+func Timeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = timeout
+	}
+}
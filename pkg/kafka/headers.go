@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"context"
+	"user-service/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+)
+
+// HeaderCarrier позволяет читать и записывать контекст трассировки (traceparent) в заголовки сообщения Kafka
+type HeaderCarrier struct {
+	Headers *[]kafka.Header
+}
+
+func (h HeaderCarrier) Get(key string) string {
+	for _, header := range *h.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h HeaderCarrier) Set(key, value string) {
+	for i, header := range *h.Headers {
+		if header.Key == key {
+			(*h.Headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*h.Headers = append(*h.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (h HeaderCarrier) Keys() []string {
+	keys := make([]string, len(*h.Headers))
+	for i, header := range *h.Headers {
+		keys[i] = header.Key
+	}
+	return keys
+}
+
+// correlationHeaders возвращает заголовки traceparent и X-Request-ID для исходящего сообщения
+func correlationHeaders(ctx context.Context) []kafka.Header {
+	var headers []kafka.Header
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier{Headers: &headers})
+	if id := logger.RequestID(ctx); id != "" {
+		headers = append(headers, kafka.Header{Key: logger.HeaderRequestID, Value: []byte(id)})
+	}
+	return headers
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fetchTimeout - сколько потребитель может не запрашивать сообщения и считаться здоровым. Получивший партиции
+// потребитель запрашивает их не реже раза в MaxWait, даже когда топик пуст.
+const fetchTimeout = 30 * time.Second
+
+// Ping проверяет, что доступен хотя бы один брокер
+func (ks *Service) Ping(ctx context.Context) error {
+	var errs []error
+	for _, broker := range ks.brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("no kafka brokers configured")
+	}
+	return errors.Join(errs...)
+}
+
+// CheckGroup проверяет состояние своего Reader, а не группы целиком: группа может быть стабильной,
+// пока этот экземпляр из нее выпал. Потребитель запрашивает сообщения только после вступления в группу
+// и получения партиций, поэтому ошибка возвращается, если запросов fetch не было дольше fetchTimeout.
+// Stats сбрасывает счетчики Reader, поэтому время последнего запроса запоминается между проверками.
+func (ks *Service) CheckGroup(context.Context) error {
+	stats := ks.Reader.Stats()
+	now := time.Now()
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if stats.Fetches > 0 {
+		ks.lastFetch = now
+	}
+	if ks.lastFetch.IsZero() {
+		return fmt.Errorf("consumer has not joined group %s yet", ks.Reader.Config().GroupID)
+	}
+	if idle := now.Sub(ks.lastFetch); idle > fetchTimeout {
+		return fmt.Errorf("consumer has not fetched from group %s for %s, rebalances: %d, errors: %d",
+			ks.Reader.Config().GroupID, idle.Round(time.Second), stats.Rebalances, stats.Errors)
+	}
+	return nil
+}
@@ -4,12 +4,21 @@ import (
 	"context"
 	"fmt"
 	"github.com/segmentio/kafka-go"
+	"sync"
 	"time"
 )
 
 type Service struct {
 	Writer *kafka.Writer
 	Reader *kafka.Reader
+	// Topic - топик, из которого читает Reader
+	Topic string
+
+	brokers []string
+
+	// mu защищает lastFetch - время, когда Reader последний раз запрашивал сообщения (см. CheckGroup)
+	mu        sync.Mutex
+	lastFetch time.Time
 }
 
 func New(brokers []string, topic string) *Service {
@@ -31,8 +40,10 @@ func New(brokers []string, topic string) *Service {
 	})
 
 	return &Service{
-		Writer: writer,
-		Reader: reader,
+		Writer:  writer,
+		Reader:  reader,
+		Topic:   topic,
+		brokers: brokers,
 	}
 }
 
@@ -51,10 +62,51 @@ func (ks *Service) ReadMessage(ctx context.Context) (kafka.Message, error) {
 	return ks.Reader.ReadMessage(ctx)
 }
 
-func (ks *Service) PublishToTopic(topic string, value []byte) error {
+// PublishToTopic публикует сообщение. В заголовки записываются контекст трассировки и идентификатор запроса
+// из ctx, чтобы потребитель продолжил трассу и логи одной операции.
+func (ks *Service) PublishToTopic(ctx context.Context, topic string, value []byte) error {
 	message := kafka.Message{
-		Topic: topic,
-		Value: value,
+		Topic:   topic,
+		Value:   value,
+		Headers: correlationHeaders(ctx),
+	}
+	return ks.Writer.WriteMessages(ctx, message)
+}
+
+// PublishBatch публикует несколько сообщений за один вызов, это быстрее, чем PublishToTopic для каждого
+func (ks *Service) PublishBatch(ctx context.Context, topic string, values [][]byte) error {
+	headers := correlationHeaders(ctx)
+	messages := make([]kafka.Message, len(values))
+	for i, value := range values {
+		messages[i] = kafka.Message{
+			Topic:   topic,
+			Value:   value,
+			Headers: headers,
+		}
+	}
+	return ks.Writer.WriteMessages(ctx, messages...)
+}
+
+// Envelope - значение сообщения со своими заголовками
+type Envelope struct {
+	Value   []byte
+	Headers map[string]string
+}
+
+// PublishEnvelopes публикует сообщения за один вызов, заголовки каждого берутся из него самого, а не из ctx.
+// Нужен, когда сообщения отправляются позже запросов, в которых возникли, например из outbox.
+func (ks *Service) PublishEnvelopes(ctx context.Context, topic string, envelopes []Envelope) error {
+	messages := make([]kafka.Message, len(envelopes))
+	for i, envelope := range envelopes {
+		headers := make([]kafka.Header, 0, len(envelope.Headers))
+		for key, value := range envelope.Headers {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+		messages[i] = kafka.Message{
+			Topic:   topic,
+			Value:   envelope.Value,
+			Headers: headers,
+		}
 	}
-	return ks.Writer.WriteMessages(context.Background(), message)
+	return ks.Writer.WriteMessages(ctx, messages...)
 }
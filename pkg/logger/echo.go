@@ -0,0 +1,39 @@
+package logger
+
+import "github.com/labstack/echo/v4"
+
+// maxRequestIDLength ограничивает длину входящего идентификатора, он попадает в логи и заголовки
+const maxRequestIDLength = 128
+
+// Middleware присваивает запросу идентификатор: берет входящий X-Request-ID, если он есть и допустим,
+// иначе создает новый. Идентификатор возвращается в ответе, сохраняется в контексте запроса
+// и в заголовке запроса, откуда его берет журнал запросов.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(HeaderRequestID)
+			if !ValidRequestID(id) {
+				id = NewRequestID()
+				req.Header.Set(HeaderRequestID, id)
+			}
+			c.Response().Header().Set(HeaderRequestID, id)
+			c.SetRequest(req.WithContext(WithRequestID(req.Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+// ValidRequestID допускает только печатные символы без пробелов и кавычек, чтобы входящий идентификатор
+// (из HTTP или заголовка сообщения Kafka) нельзя было использовать для подделки строк лога
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if ch := id[i]; ch <= ' ' || ch > '~' || ch == '"' || ch == '\\' {
+			return false
+		}
+	}
+	return true
+}
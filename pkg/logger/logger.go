@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID - заголовок с идентификатором запроса в HTTP и в сообщениях Kafka
+const HeaderRequestID = "X-Request-ID"
+
+// Имена полей корреляции в логах
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldSpanID    = "span_id"
+)
+
+type requestIDKey struct{}
+
+type fieldsKey struct{}
+
+// WithRequestID сохраняет идентификатор запроса в контексте
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID создает случайный идентификатор запроса
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithFields добавляет поля ко всем строкам лога, записанным через FromContext с этим контекстом
+func WithFields(ctx context.Context, fields log.Fields) context.Context {
+	merged := make(log.Fields, len(fields))
+	if parent, ok := ctx.Value(fieldsKey{}).(log.Fields); ok {
+		for key, value := range parent {
+			merged[key] = value
+		}
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext возвращает логгер, который отмечает каждую строку идентификатором запроса,
+// идентификаторами трассы и спана и полями из WithFields
+func FromContext(ctx context.Context) *log.Entry {
+	fields := log.Fields{}
+	if parent, ok := ctx.Value(fieldsKey{}).(log.Fields); ok {
+		for key, value := range parent {
+			fields[key] = value
+		}
+	}
+	if id := RequestID(ctx); id != "" {
+		fields[FieldRequestID] = id
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields[FieldTraceID] = sc.TraceID().String()
+		fields[FieldSpanID] = sc.SpanID().String()
+	}
+	return log.WithContext(ctx).WithFields(fields)
+}
+
+// Detach возвращает новый контекст с идентификатором запроса, полями лога и контекстом трассировки из ctx,
+// но без его отмены. Нужен для фоновой работы, которая продолжается после ответа на запрос: спаны этой
+// работы остаются в трассе запроса. Сам спан запроса не переносится, чтобы не писать в завершенный спан,
+// поэтому долгой работе стоит начать свой дочерний спан.
+func Detach(ctx context.Context) context.Context {
+	detached := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+	detached = WithRequestID(detached, RequestID(ctx))
+	if fields, ok := ctx.Value(fieldsKey{}).(log.Fields); ok {
+		detached = context.WithValue(detached, fieldsKey{}, fields)
+	}
+	return detached
+}
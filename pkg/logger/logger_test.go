@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"incoming kept", "req-42", true},
+		{"missing generated", "", false},
+		{"unsafe replaced", "evil\" injected=\"1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(Middleware())
+			var got string
+			e.GET("/", func(c echo.Context) error {
+				got = RequestID(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(HeaderRequestID, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if got == "" || rec.Header().Get(HeaderRequestID) != got {
+				t.Fatalf("context id %q, response id %q", got, rec.Header().Get(HeaderRequestID))
+			}
+			if (got == tt.incoming) != tt.keep {
+				t.Fatalf("got id %q for incoming %q", got, tt.incoming)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithFields(ctx, log.Fields{"kafka_offset": 10})
+	ctx = WithFields(ctx, log.Fields{"kafka_partition": 3})
+
+	entry := FromContext(ctx)
+	for key, want := range map[string]interface{}{
+		FieldRequestID:    "req-1",
+		FieldTraceID:      sc.TraceID().String(),
+		FieldSpanID:       sc.SpanID().String(),
+		"kafka_offset":    10,
+		"kafka_partition": 3,
+	} {
+		if entry.Data[key] != want {
+			t.Errorf("%s = %v, want %v", key, entry.Data[key], want)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	detached := Detach(cancelled)
+	if RequestID(detached) != "req-1" || FromContext(detached).Data["kafka_offset"] != 10 {
+		t.Fatalf("detached context lost correlation: %v", FromContext(detached).Data)
+	}
+	if !trace.SpanContextFromContext(detached).Equal(sc) {
+		t.Fatalf("detached context lost span context: %v", FromContext(detached).Data)
+	}
+	if detached.Err() != nil {
+		t.Fatal("detached context must not be cancelled with the request")
+	}
+}
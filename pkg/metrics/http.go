@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unmatchedRoute - метка маршрута для запросов, не подошедших ни к одному маршруту
+const unmatchedRoute = "unmatched"
+
+// Middleware измеряет длительность запросов. Маршрут берется из шаблона (/users/:id), а не из URL,
+// чтобы число рядов не росло с числом пользователей. Ошибка обработчика еще не записана в ответ,
+// поэтому ее статус определяет errorStatus - тот же, по которому ошибку отдаст HTTPErrorHandler echo.
+func Middleware(errorStatus func(error) int) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				status = errorStatus(err)
+			}
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			HTTPRequestDuration.WithLabelValues(c.Request().Method, route, strconv.Itoa(status)).
+				Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// observed возвращает число запросов по меткам "method route status"
+func observed(t *testing.T) map[string]uint64 {
+	t.Helper()
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "user_service_http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counts[labels["method"]+" "+labels["route"]+" "+labels["status"]] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+	return counts
+}
+
+func TestMiddleware(t *testing.T) {
+	errTeapot := errors.New("teapot")
+	e := echo.New()
+	e.Use(Middleware(func(err error) int {
+		if errors.Is(err, errTeapot) {
+			return http.StatusTeapot
+		}
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return httpErr.Code
+		}
+		return http.StatusInternalServerError
+	}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return errTeapot
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, target := range []string{"/users/1", "/users/2", "/users/0", "/missing"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	counts := observed(t)
+	for key, want := range map[string]uint64{
+		"GET /users/:id 200": 2,
+		"GET /users/:id 418": 1,
+		"GET unmatched 404":  1,
+	} {
+		if got := counts[key]; got != want {
+			t.Errorf("%s: got %d requests, wanted %d (all: %v)", key, got, want, counts)
+		}
+	}
+}
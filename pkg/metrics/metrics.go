@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace - общий префикс метрик сервиса
+const namespace = "user_service"
+
+// Причины, по которым сообщение Kafka не обработано
+const (
+	ReasonRead       = "read"
+	ReasonDecode     = "decode"
+	ReasonValidation = "validation"
+	ReasonEnrich     = "enrich"
+	ReasonSave       = "save"
+)
+
+// Registry - реестр метрик сервиса. Метрики процесса и Go runtime регистрируются в нем же,
+// глобальный реестр prometheus не используется.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration - длительность HTTP-запросов по шаблону маршрута, методу и статусу
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// ConsumerProcessed - сообщения Kafka, сохраненные в базу
+	ConsumerProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "messages_processed_total",
+		Help:      "Kafka messages enriched and saved.",
+	})
+
+	// ConsumerFailed - сообщения Kafka, которые не удалось обработать, по причине
+	ConsumerFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "messages_failed_total",
+		Help:      "Kafka messages that failed processing by reason.",
+	}, []string{"reason"})
+
+	// ConsumerLag - сколько сообщений партиции еще не прочитано на момент последнего чтения
+	ConsumerLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "lag",
+		Help:      "Messages left in the partition after the last read message.",
+	}, []string{"partition"})
+
+	// EnrichmentDuration - длительность запросов к сервисам обогащения
+	EnrichmentDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "enrichment",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of enrichment API calls by provider.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider"})
+
+	// EnrichmentErrors - неудачные запросы к сервисам обогащения
+	EnrichmentErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "enrichment",
+		Name:      "errors_total",
+		Help:      "Failed enrichment API calls by provider.",
+	}, []string{"provider"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		ConsumerProcessed,
+		ConsumerFailed,
+		ConsumerLag,
+		EnrichmentDuration,
+		EnrichmentErrors,
+	)
+}
+
+// Handler отдает метрики реестра в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// CounterFunc регистрирует счетчик, значение которого читается из fn при каждом сборе метрик.
+// Так экспортируются счетчики, которые уже ведут другие пакеты, например кэш.
+func CounterFunc(subsystem, name, help string, labels prometheus.Labels, fn func() float64) {
+	Registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	}, fn))
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector экспортирует статистику пула соединений pgx на момент сбора метрик
+type PoolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	emptyAcquire *prometheus.Desc
+	waitSeconds  *prometheus.Desc
+}
+
+// NewPoolCollector создает сборщик, stat возвращает текущую статистику пула или nil, если ее нет
+func NewPoolCollector(stat func() *pgxpool.Stat) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &PoolCollector{
+		stat:         stat,
+		acquired:     desc("acquired_conns", "Connections currently acquired from the pool."),
+		idle:         desc("idle_conns", "Idle connections in the pool."),
+		total:        desc("total_conns", "Total connections in the pool."),
+		max:          desc("max_conns", "Maximum size of the pool."),
+		acquires:     desc("acquires_total", "Successful connection acquires."),
+		emptyAcquire: desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		waitSeconds:  desc("acquire_wait_seconds_total", "Total time spent waiting for a connection."),
+	}
+}
+
+func (p *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.acquired
+	ch <- p.idle
+	ch <- p.total
+	ch <- p.max
+	ch <- p.acquires
+	ch <- p.emptyAcquire
+	ch <- p.waitSeconds
+}
+
+func (p *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := p.stat()
+	if stat == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(p.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(p.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(p.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(p.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(p.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.waitSeconds, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
@@ -0,0 +1,96 @@
+// Package normalize приводит части ФИО к каноническому виду, чтобы "ivan", "IVAN " и "Ivan"
+// сохранялись одинаково.
+package normalize
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer нормализует имена с учетом правил регистра языка. Нулевой указатель
+// работает как Normalizer без языка (language.Und).
+type Normalizer struct {
+	lang language.Tag
+}
+
+func New(opts ...Option) *Normalizer {
+	n := &Normalizer{lang: language.Und}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// варианты апострофа и дефиса, которые приводятся к ' и -
+var punctuation = strings.NewReplacer(
+	"’", "'", "‘", "'", "ʼ", "'", "ʻ", "'", "`", "'", "´", "'",
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-",
+)
+
+// Name нормализует часть ФИО:
+//   - Unicode NFC, апострофы и дефисы приводятся к ' и -;
+//   - пробелы по краям убираются, подряд идущие схлопываются, вокруг дефиса и апострофа удаляются;
+//   - каждая часть имени, набранная в одном регистре, пишется с заглавной буквы по правилам языка.
+//     После апострофа заглавная ставится только за однобуквенной приставкой: O'Connor, но Мар'яна.
+//     Части в смешанном регистре (McDonald) не меняются.
+func (n *Normalizer) Name(s string) string {
+	s = punctuation.Replace(norm.NFC.String(s))
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return ""
+	}
+	for _, sep := range []string{"-", "'"} {
+		s = strings.ReplaceAll(s, " "+sep, sep)
+		s = strings.ReplaceAll(s, sep+" ", sep)
+	}
+
+	lang := language.Und
+	if n != nil {
+		lang = n.lang
+	}
+	// Caser хранит состояние, поэтому создается на каждый вызов
+	title, lower := cases.Title(lang), cases.Lower(lang)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	var prevSep rune
+	prevLen := 0
+	for len(s) > 0 {
+		end := strings.IndexAny(s, " -'")
+		if end < 0 {
+			end = len(s)
+		}
+		part := s[:end]
+		switch {
+		case !singleCase(part):
+		case prevSep == '\'' && prevLen > 1:
+			part = lower.String(part)
+		default:
+			part = title.String(part)
+		}
+		b.WriteString(part)
+		prevLen = len([]rune(part))
+
+		if end == len(s) {
+			break
+		}
+		prevSep = rune(s[end])
+		b.WriteByte(s[end])
+		s = s[end+1:]
+	}
+	return b.String()
+}
+
+// singleCase проверяет, что все буквы строки в одном регистре
+func singleCase(s string) bool {
+	var upper, lower bool
+	for _, r := range s {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+	}
+	return !(upper && lower)
+}
@@ -0,0 +1,41 @@
+package normalize
+
+import "testing"
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		lang string
+		in   string
+		want string
+	}{
+		{in: "ivan", want: "Ivan"},
+		{in: "  IVAN ", want: "Ivan"},
+		{in: "Ivan", want: "Ivan"},
+		{in: "анна  -  мария", want: "Анна-Мария"},
+		{in: "САЛТЫКОВ–ЩЕДРИН", want: "Салтыков-Щедрин"},
+		{in: "o’connor", want: "O'Connor"},
+		{in: "мар'яна", want: "Мар'яна"},
+		{in: "McDonald", want: "McDonald"},
+		{in: "de la  cruz", want: "De La Cruz"},
+		// e + combining acute (NFD) приводится к é (NFC)
+		{in: "rene\u0301e", want: "Ren\u00e9e"},
+		{lang: "tr", in: "istanbul", want: "İstanbul"},
+		{lang: "tr", in: "IŞIK", want: "Işık"},
+		{lang: "nl", in: "ijsbrand", want: "IJsbrand"},
+		{in: "   ", want: ""},
+	}
+
+	for _, test := range tests {
+		n := New(Language(test.lang))
+		if got := n.Name(test.in); got != test.want {
+			t.Errorf("Name(%q) with %q = %q, wanted %q", test.in, test.lang, got, test.want)
+		}
+	}
+}
+
+func TestNilNormalizer(t *testing.T) {
+	var n *Normalizer
+	if got, want := n.Name(" petrov "), "Petrov"; got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
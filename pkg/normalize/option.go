@@ -0,0 +1,15 @@
+package normalize
+
+import "golang.org/x/text/language"
+
+type Option func(*Normalizer)
+
+// Language задает язык для правил регистра, например tr (İstanbul) или nl (IJsselmeer).
+// Неизвестный тег означает правила без языка.
+func Language(tag string) Option {
+	return func(n *Normalizer) {
+		if lang, err := language.Parse(tag); err == nil {
+			n.lang = lang
+		}
+	}
+}
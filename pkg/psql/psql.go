@@ -21,6 +21,10 @@ type PgxPool interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+	Ping(ctx context.Context) error
 }
 
 type PgxTx interface {
@@ -52,6 +56,7 @@ func New(url string, opts ...Option) (*Postgres, error) {
 	}
 
 	poolConfig.MaxConns = int32(pg.maxPoolSize)
+	poolConfig.ConnConfig.Tracer = newTracer()
 
 	for pg.connAttempts > 0 {
 		pg.Pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
@@ -76,3 +81,16 @@ func (p *Postgres) Close() {
 		p.Pool.Close()
 	}
 }
+
+// Stat возвращает статистику пула соединений или nil, если пул создан не через pgxpool
+func (p *Postgres) Stat() *pgxpool.Stat {
+	if pool, ok := p.Pool.(*pgxpool.Pool); ok {
+		return pool.Stat()
+	}
+	return nil
+}
+
+// Ping проверяет, что пул может получить соединение и база отвечает
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.Pool.Ping(ctx)
+}
@@ -0,0 +1,76 @@
+package psql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerScope - имя библиотеки инструментирования запросов в спанах
+const tracerScope = "user-service/pkg/psql"
+
+// tracer создает клиентский спан на каждый запрос и пакет запросов. В спан пишется текст запроса
+// без аргументов: в аргументах персональные данные.
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func newTracer() *tracer {
+	return &tracer{tracer: otel.Tracer(tracerScope)}
+}
+
+func (t *tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := sqlOperation(data.SQL)
+	ctx, _ = t.tracer.Start(ctx, "db "+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation(operation),
+		semconv.DBStatement(data.SQL),
+	))
+	return ctx
+}
+
+func (t *tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err)
+}
+
+func (t *tracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "db batch", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation("batch"),
+	))
+	return ctx
+}
+
+func (t *tracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("query", trace.WithAttributes(semconv.DBStatement(data.SQL)))
+	if data.Err != nil {
+		span.RecordError(data.Err)
+	}
+}
+
+func (t *tracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	endSpan(trace.SpanFromContext(ctx), data.Err)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation - первое слово запроса в верхнем регистре (SELECT, INSERT, WITH)
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
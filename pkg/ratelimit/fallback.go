@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPrimaryTimeout = 100 * time.Millisecond
+	defaultRetryPrimary   = 5 * time.Second
+)
+
+// Fallback обращается к основному лимитеру, а при его ошибке на время retry переключается на запасной.
+// Так недоступный Redis не блокирует запросы и не снимает ограничения совсем.
+type Fallback struct {
+	primary   Limiter
+	secondary Limiter
+	timeout   time.Duration
+	retry     time.Duration
+
+	mu        sync.Mutex
+	downUntil time.Time
+
+	now func() time.Time
+}
+
+func NewFallback(primary, secondary Limiter) *Fallback {
+	return &Fallback{
+		primary:   primary,
+		secondary: secondary,
+		timeout:   defaultPrimaryTimeout,
+		retry:     defaultRetryPrimary,
+		now:       time.Now,
+	}
+}
+
+func (f *Fallback) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if f.primaryDown() {
+		return f.secondary.Allow(ctx, key, limit)
+	}
+
+	primaryCtx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	res, err := f.primary.Allow(primaryCtx, key, limit)
+	if err == nil {
+		return res, nil
+	}
+
+	f.mu.Lock()
+	f.downUntil = f.now().Add(f.retry)
+	f.mu.Unlock()
+	log.Warn("Rate limiter is unavailable, using in-memory limits:", err)
+
+	return f.secondary.Allow(ctx, key, limit)
+}
+
+func (f *Fallback) primaryDown() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now().Before(f.downUntil)
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery - через сколько вызовов из памяти удаляются ключи с полностью восстановленным лимитом
+const sweepEvery = 1000
+
+// Memory - лимитер в памяти процесса. Лимит считается отдельно на каждом экземпляре сервиса.
+type Memory struct {
+	mu    sync.Mutex
+	tats  map[string]int64
+	calls int
+
+	now func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		tats: make(map[string]int64),
+		now:  time.Now,
+	}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UnixMilli()
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		for k, tat := range m.tats {
+			if tat <= now {
+				delete(m.tats, k)
+			}
+		}
+	}
+
+	res, tat := gcra(now, m.tats[key], limit)
+	m.tats[key] = tat
+	return res, nil
+}
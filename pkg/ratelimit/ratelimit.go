@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit - не больше Rate запросов за Period, из них до Burst подряд
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// interval - интервал между запросами при равномерной нагрузке
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// burst возвращает размер всплеска, по умолчанию равный Rate
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// Result - решение по запросу
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter - через сколько запрос будет разрешен, если сейчас отказано
+	RetryAfter time.Duration
+	// ResetAfter - через сколько лимит восстановится полностью
+	ResetAfter time.Duration
+}
+
+// Limiter решает, пропустить ли очередной запрос по ключу клиента. Лимиты считаются
+// по алгоритму GCRA: для ключа хранится теоретическое время прихода следующего запроса (TAT).
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// gcra вычисляет решение и новый TAT по текущему TAT, все значения - в миллисекундах от общего начала
+func gcra(now, tat int64, limit Limit) (Result, int64) {
+	interval := limit.interval().Milliseconds()
+	if interval < 1 {
+		interval = 1
+	}
+	burst := int64(limit.burst())
+
+	if tat < now {
+		tat = now
+	}
+	newTAT := tat + interval
+	allowAt := newTAT - burst*interval
+
+	res := Result{Limit: int(burst)}
+	if diff := now - allowAt; diff >= 0 {
+		res.Allowed = true
+		res.Remaining = int(diff / interval)
+		res.ResetAfter = time.Duration(newTAT-now) * time.Millisecond
+		return res, newTAT
+	}
+
+	res.RetryAfter = time.Duration(allowAt-now) * time.Millisecond
+	res.ResetAfter = time.Duration(tat-now) * time.Millisecond
+	return res, tat
+}
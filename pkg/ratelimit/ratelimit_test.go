@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestLimiters(t *testing.T) {
+	mr := miniredis.RunT(t)
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	redisLimiter := NewRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	redisLimiter.now = clock
+	memoryLimiter := NewMemory()
+	memoryLimiter.now = clock
+
+	limiters := map[string]Limiter{
+		"redis":  redisLimiter,
+		"memory": memoryLimiter,
+	}
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 3}
+
+	for name, limiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			key := name + ":client"
+
+			for i := 0; i < 3; i++ {
+				res, err := limiter.Allow(ctx, key, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !res.Allowed || res.Remaining != 2-i || res.Limit != 3 {
+					t.Errorf("request %d: unexpected result %+v", i, res)
+				}
+			}
+
+			res, _ := limiter.Allow(ctx, key, limit)
+			if res.Allowed {
+				t.Fatal("expected burst to be exhausted")
+			}
+			if res.RetryAfter != 100*time.Millisecond {
+				t.Errorf("got retry after %s, wanted 100ms", res.RetryAfter)
+			}
+
+			// другие клиенты не затронуты
+			if res, _ := limiter.Allow(ctx, name+":other", limit); !res.Allowed {
+				t.Error("expected other client to be allowed")
+			}
+
+			// через интервал восстанавливается один запрос
+			now = now.Add(100 * time.Millisecond)
+			if res, _ := limiter.Allow(ctx, key, limit); !res.Allowed || res.Remaining != 0 {
+				t.Errorf("unexpected result after interval %+v", res)
+			}
+		})
+	}
+}
+
+func TestFallbackUsesSecondaryWhenPrimaryFails(t *testing.T) {
+	mr := miniredis.RunT(t)
+	primary := NewRedis(redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1}))
+	limiter := NewFallback(primary, NewMemory())
+	mr.Close()
+
+	limit := Limit{Rate: 1, Period: time.Minute}
+	res, err := limiter.Allow(context.Background(), "client", limit)
+	if err != nil || !res.Allowed {
+		t.Fatalf("got %+v, %v, wanted allowed by secondary", res, err)
+	}
+	// состояние теперь в памяти, лимит продолжает действовать
+	if res, _ := limiter.Allow(context.Background(), "client", limit); res.Allowed {
+		t.Error("expected secondary limiter to enforce the limit")
+	}
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// gcraScript - тот же расчет, что и gcra, но атомарно в Redis, чтобы лимит был общим для всех экземпляров.
+// Возвращает allowed, remaining, retry_after_ms, reset_after_ms.
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - burst * interval
+local diff = now - allow_at
+
+if diff < 0 then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call("SET", KEYS[1], new_tat, "PX", new_tat - now)
+return {1, math.floor(diff / interval), 0, new_tat - now}`)
+
+const keyPrefix = "ratelimit:"
+
+// Redis - распределенный лимитер, состояние хранится в Redis
+type Redis struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	interval := limit.interval().Milliseconds()
+	if interval < 1 {
+		interval = 1
+	}
+
+	values, err := gcraScript.Run(ctx, r.client, []string{keyPrefix + key},
+		r.now().UnixMilli(), interval, limit.burst()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.burst(),
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -1,12 +1,25 @@
 package redis
 
-import "github.com/go-redis/redis/v8"
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
 
 func New(addr string, password string) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
 	})
+	rdb.AddHook(newTracingHook())
 
 	return rdb
 }
+
+// Ping проверяет, что Redis доступен, не дольше timeout
+func Ping(rdb *redis.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return rdb.Ping(ctx).Err()
+}
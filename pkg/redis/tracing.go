@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerScope - имя библиотеки инструментирования команд Redis в спанах
+const tracerScope = "user-service/pkg/redis"
+
+// tracingHook создает клиентский спан на каждую команду и конвейер команд. Аргументы команд
+// (ключи и значения) в спан не пишутся, только имя команды.
+type tracingHook struct {
+	tracer trace.Tracer
+}
+
+func newTracingHook() tracingHook {
+	return tracingHook{tracer: otel.Tracer(tracerScope)}
+}
+
+func (h tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = h.tracer.Start(ctx, "redis "+cmd.Name(), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemRedis,
+		semconv.DBOperation(cmd.Name()),
+	))
+	return ctx, nil
+}
+
+func (h tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endSpan(trace.SpanFromContext(ctx), cmd.Err())
+	return nil
+}
+
+func (h tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name()
+	}
+	ctx, _ = h.tracer.Start(ctx, "redis pipeline", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		semconv.DBSystemRedis,
+		semconv.DBOperation(strings.Join(names, " ")),
+		attribute.Int("db.redis.pipeline_length", len(cmds)),
+	))
+	return ctx, nil
+}
+
+func (h tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			err = cmd.Err()
+			break
+		}
+	}
+	endSpan(trace.SpanFromContext(ctx), err)
+	return nil
+}
+
+// endSpan завершает спан, промах (redis.Nil) ошибкой не считается
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != redis.Nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
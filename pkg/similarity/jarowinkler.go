@@ -0,0 +1,87 @@
+// Package similarity сравнивает строки для поиска похожих записей
+package similarity
+
+// Параметры Винклера: бонус за общий префикс длиной до maxPrefix
+const (
+	prefixScale = 0.1
+	maxPrefix   = 4
+)
+
+// Jaro возвращает сходство Джаро от 0 (ничего общего) до 1 (строки равны), сравнение идет по символам Unicode
+func Jaro(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i, r := range ra {
+		from, to := max(0, i-window), min(len(rb), i+window+1)
+		for j := from; j < to; j++ {
+			if !matchedB[j] && rb[j] == r {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// транспозиции - совпавшие символы, стоящие в другом порядке
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// JaroWinkler - сходство Джаро с бонусом за общий префикс, лучше подходит для имен,
+// которые обычно различаются окончаниями
+func JaroWinkler(a, b string) float64 {
+	jaro := Jaro(a, b)
+
+	ra, rb := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < min(maxPrefix, min(len(ra), len(rb))) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*prefixScale*(1-jaro)
+}
+
+// в go 1.20 нет встроенных min и max
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,32 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "martha", b: "marhta", want: 0.961},
+		{a: "dwayne", b: "duane", want: 0.84},
+		{a: "dixon", b: "dicksonx", want: 0.813},
+		{a: "petr", b: "pyotr", want: 0.805},
+		{a: "ушаков", b: "ушаков", want: 1},
+		{a: "", b: "", want: 1},
+		{a: "abc", b: "", want: 0},
+		{a: "abc", b: "xyz", want: 0},
+	}
+
+	for _, test := range tests {
+		got := JaroWinkler(test.a, test.b)
+		if math.Abs(got-test.want) > 0.001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.3f, wanted %.3f", test.a, test.b, got, test.want)
+		}
+		if back := JaroWinkler(test.b, test.a); math.Abs(got-back) > 1e-9 {
+			t.Errorf("JaroWinkler is not symmetric for %q and %q: %.3f and %.3f", test.a, test.b, got, back)
+		}
+	}
+}
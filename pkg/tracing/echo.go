@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope - имя библиотеки инструментирования HTTP-сервера в спанах
+const scope = "user-service/pkg/tracing"
+
+// Middleware создает серверный спан на каждый запрос, продолжая трассу из заголовка traceparent.
+// Спан называется по шаблону маршрута, контекст со спаном передается обработчику через запрос.
+// Статус ошибки обработчика определяет errorStatus - тот же, по которому ошибку отдаст HTTPErrorHandler echo.
+func Middleware(errorStatus func(error) int) echo.MiddlewareFunc {
+	tracer := otel.Tracer(scope)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			// без маршрута спан называется только методом, чтобы имена не зависели от URL
+			name := req.Method
+			attrs := []attribute.KeyValue{semconv.HTTPMethod(req.Method)}
+			if route := c.Path(); route != "" {
+				name += " " + route
+				attrs = append(attrs, semconv.HTTPRoute(route))
+			}
+			ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				span.RecordError(err)
+				status = errorStatus(err)
+			}
+			span.SetAttributes(semconv.HTTPStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return err
+		}
+	}
+}
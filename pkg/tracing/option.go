@@ -0,0 +1,24 @@
+package tracing
+
+type Option func(*config)
+
+// Exporter задает, куда отправлять спаны: none, stdout или otlp
+func Exporter(name string) Option {
+	return func(c *config) {
+		c.exporter = name
+	}
+}
+
+// Endpoint задает адрес приемника OTLP/gRPC, например http://localhost:4317 (https - с TLS)
+func Endpoint(url string) Option {
+	return func(c *config) {
+		c.endpoint = url
+	}
+}
+
+// SampleRatio задает долю записываемых трасс от 0 до 1
+func SampleRatio(ratio float64) Option {
+	return func(c *config) {
+		c.sampleRatio = ratio
+	}
+}
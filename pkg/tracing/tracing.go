@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Экспортеры спанов
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+const (
+	defaultEndpoint    = "http://localhost:4317"
+	defaultSampleRatio = 1.0
+)
+
+type config struct {
+	exporter    string
+	endpoint    string
+	sampleRatio float64
+}
+
+// Setup настраивает глобальный TracerProvider и распространение контекста W3C (traceparent, baggage).
+// Контекст распространяется и без экспортера: входящий traceparent передается дальше в Kafka и HTTP.
+// Возвращаемая функция отправляет накопленные спаны и останавливает провайдер.
+func Setup(serviceName string, opts ...Option) (func(context.Context) error, error) {
+	cfg := config{
+		exporter:    ExporterNone,
+		endpoint:    defaultEndpoint,
+		sampleRatio: defaultSampleRatio,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		var err error
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("tracing - Setup - stdouttrace.New: %w", err)
+		}
+	case ExporterOTLP:
+		opts, err := otlpOptions(cfg.endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("tracing - Setup - otlpOptions: %w", err)
+		}
+		// соединение устанавливается в фоне, недоступный приемник не мешает запуску
+		exporter, err = otlptracegrpc.New(context.Background(), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("tracing - Setup - otlptracegrpc.New: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", cfg.exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing - Setup - resource.Merge: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// решение о записи принимает начало трассы, чтобы трасса не рвалась посередине
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// otlpOptions переводит адрес приемника вида http://host:4317 в опции экспортера OTLP/gRPC:
+// схема http означает соединение без TLS
+func otlpOptions(endpoint string) ([]otlptracegrpc.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http":
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host), otlptracegrpc.WithInsecure()}, nil
+	case "https":
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host)}, nil
+	default:
+		return nil, fmt.Errorf("endpoint must be an http or https URL, got %q", endpoint)
+	}
+}
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := newRecorder(t)
+
+	e := echo.New()
+	e.Use(Middleware(func(err error) int {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return httpErr.Code
+		}
+		return http.StatusInternalServerError
+	}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return echo.NewHTTPError(http.StatusServiceUnavailable)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/0", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, wanted 2", len(spans))
+	}
+	if got, want := spans[0].Name(), "GET /users/:id"; got != want {
+		t.Errorf("got span name %q, wanted %q", got, want)
+	}
+	if got, want := spans[0].SpanContext().TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("got trace id %s, wanted the incoming %s", got, want)
+	}
+	if got, want := spans[0].Parent().SpanID().String(), "00f067aa0ba902b7"; got != want {
+		t.Errorf("got parent span id %s, wanted %s", got, want)
+	}
+	if got := spans[1].Status().Code; got != codes.Error {
+		t.Errorf("got status %v for 503, wanted Error", got)
+	}
+}
+
+func TestOTLPOptions(t *testing.T) {
+	for endpoint, valid := range map[string]bool{
+		"http://collector:4317":  true,
+		"https://collector:4317": true,
+		"collector:4317":         false,
+		"ftp://collector":        false,
+	} {
+		if _, err := otlpOptions(endpoint); (err == nil) != valid {
+			t.Errorf("%s: got error %v, wanted valid %v", endpoint, err, valid)
+		}
+	}
+}
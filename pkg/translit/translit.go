@@ -0,0 +1,116 @@
+// Package translit транслитерирует кириллицу латиницей по ГОСТ 7.79-2000 (система Б) и ICAO Doc 9303.
+package translit
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Scheme - схема транслитерации: строчная буква кириллицы -> латиница.
+// Нулевой указатель оставляет строку без изменений.
+type Scheme struct {
+	name  string
+	table map[rune]string
+	// special - правило для буквы, зависящее от следующей буквы (ц в ГОСТ 7.79)
+	special func(r, next rune) (string, bool)
+}
+
+// ICAO - ICAO Doc 9303, используется в загранпаспортах: только латинские буквы без диакритики
+var ICAO = &Scheme{
+	name: "icao",
+	table: map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+		'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+		'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+		'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+		// украинский и белорусский алфавиты
+		'є': "ie", 'і': "i", 'ї': "i", 'ґ': "g", 'ў': "u",
+	},
+}
+
+// GOST779 - ГОСТ 7.79-2000, система Б: обратимая транслитерация с диграфами и апострофами
+var GOST779 = &Scheme{
+	name: "gost",
+	table: map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z",
+		'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+		'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh", 'щ': "shh",
+		'ъ': "``", 'ы': "y'", 'ь': "`", 'э': "e`", 'ю': "yu", 'я': "ya",
+		'є': "ye", 'і': "i`", 'ї': "yi", 'ґ': "g`", 'ў': "u`",
+	},
+	// ц перед е, и, ы, й передается как c
+	special: func(r, next rune) (string, bool) {
+		if r != 'ц' {
+			return "", false
+		}
+		switch unicode.ToLower(next) {
+		case 'е', 'и', 'ы', 'й', 'і', 'є':
+			return "c", true
+		}
+		return "", false
+	},
+}
+
+// Lookup возвращает схему по имени: icao, gost или none (nil - без транслитерации)
+func Lookup(name string) (*Scheme, error) {
+	switch strings.ToLower(name) {
+	case "icao":
+		return ICAO, nil
+	case "gost", "gost779":
+		return GOST779, nil
+	case "", "none":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown transliteration scheme %q", name)
+}
+
+func (s *Scheme) Name() string {
+	if s == nil {
+		return "none"
+	}
+	return s.name
+}
+
+// String транслитерирует строку. Символы не из кириллицы не меняются. Регистр сохраняется:
+// заглавная буква внутри слова в верхнем регистре (ЩУКА) дает заглавный диграф (SHCHUKA), иначе - Shchuka.
+func (s *Scheme) String(str string) string {
+	if s == nil {
+		return str
+	}
+
+	runes := []rune(str)
+	var b strings.Builder
+	b.Grow(len(str))
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+		latin, ok := s.table[lower]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		var prev, next rune
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		if s.special != nil {
+			if special, ok := s.special(lower, next); ok {
+				latin = special
+			}
+		}
+
+		if r != lower && latin != "" {
+			if unicode.IsUpper(next) || (!unicode.IsLetter(next) && unicode.IsUpper(prev)) {
+				latin = strings.ToUpper(latin)
+			} else {
+				latin = strings.ToUpper(latin[:1]) + latin[1:]
+			}
+		}
+		b.WriteString(latin)
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package translit
+
+import "testing"
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		scheme *Scheme
+		in     string
+		want   string
+	}{
+		{scheme: ICAO, in: "Дмитрий", want: "Dmitrii"},
+		{scheme: ICAO, in: "Щукина-Юрьева", want: "Shchukina-Iureva"},
+		{scheme: ICAO, in: "Хрущёв", want: "Khrushchev"},
+		{scheme: ICAO, in: "ЩУКА", want: "SHCHUKA"},
+		{scheme: ICAO, in: "Олександр Їжак", want: "Oleksandr Izhak"},
+		{scheme: ICAO, in: "John", want: "John"},
+		{scheme: GOST779, in: "Дмитрий", want: "Dmitrij"},
+		{scheme: GOST779, in: "Цветков", want: "Czvetkov"},
+		{scheme: GOST779, in: "Цыганов", want: "Cy'ganov"},
+		{scheme: GOST779, in: "Щукина Юлия", want: "Shhukina Yuliya"},
+		{scheme: GOST779, in: "Подъячев", want: "Pod``yachev"},
+		{scheme: nil, in: "Дмитрий", want: "Дмитрий"},
+	}
+
+	for _, test := range tests {
+		if got := test.scheme.String(test.in); got != test.want {
+			t.Errorf("%s: String(%q) = %q, wanted %q", test.scheme.Name(), test.in, got, test.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for name, want := range map[string]*Scheme{"icao": ICAO, "GOST": GOST779, "none": nil, "": nil} {
+		scheme, err := Lookup(name)
+		if err != nil || scheme != want {
+			t.Errorf("Lookup(%q) = %v, %v", name, scheme.Name(), err)
+		}
+	}
+	if _, err := Lookup("bgn"); err == nil {
+		t.Error("expected error for unknown scheme")
+	}
+}
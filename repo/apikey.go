@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"user-service/api_clients/model"
+)
+
+// ErrAPIKeyNotFound возвращается, если ключа нет или он отозван
+var ErrAPIKeyNotFound = fmt.Errorf("api key %w", ErrNotFound)
+
+// APIKeyRepo хранит API-ключи. Вместо ключей передаются их хэши.
+type APIKeyRepo interface {
+	CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (model.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	// GetAPIKeyByHash ищет неотозванный ключ по хэшу, срок действия не проверяется
+	GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error)
+	// RotateAPIKey заменяет хэш и префикс неотозванного ключа, старый ключ сразу перестает действовать
+	RotateAPIKey(ctx context.Context, id int64, prefix, hash string) (model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	// AddAPIKeyUsage прибавляет накопленные счетчики запросов
+	AddAPIKeyUsage(ctx context.Context, usage []model.APIKeyUsage) error
+}
@@ -0,0 +1,32 @@
+package repo
+
+import "context"
+
+// Источники изменений, попадающие в журнал аудита
+const (
+	SourceKafka     = "kafka"
+	SourceHTTP      = "http"
+	SourceRetention = "retention"
+	SourceImport    = "import"
+)
+
+// Actor описывает, кто и через какой канал меняет данные
+type Actor struct {
+	Name   string
+	Source string
+}
+
+type actorKey struct{}
+
+// WithActor кладет в контекст автора изменений, репозиторий записывает его в журнал аудита
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает автора изменений из контекста или системного, если он не задан
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Name: "system", Source: "unknown"}
+}
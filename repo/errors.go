@@ -0,0 +1,12 @@
+package repo
+
+import "errors"
+
+// Общие виды ошибок хранилища. Конкретные ошибки оборачивают их, поэтому вызывающий может
+// проверять и конкретную ошибку (ErrUserNotFound), и ее вид (ErrNotFound).
+var (
+	// ErrNotFound - запись не найдена
+	ErrNotFound = errors.New("not found")
+	// ErrConflict - запись противоречит уже существующей, например совпал хэш нового API-ключа
+	ErrConflict = errors.New("conflict")
+)
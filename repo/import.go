@@ -0,0 +1,20 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"user-service/api_clients/model"
+)
+
+// ErrImportNotFound возвращается, если задачи импорта с указанным id нет
+var ErrImportNotFound = fmt.Errorf("import %w", ErrNotFound)
+
+// ImportRepo хранит задачи импорта и ошибки по строкам
+type ImportRepo interface {
+	CreateImport(ctx context.Context, imp model.Import) (int64, error)
+	// UpdateImport сохраняет статус, счетчики, ошибку и время завершения задачи
+	UpdateImport(ctx context.Context, imp model.Import) error
+	AddImportErrors(ctx context.Context, importID int64, rowErrors []model.ImportRowError) error
+	// GetImport возвращает задачу и страницу ее ошибок по строкам
+	GetImport(ctx context.Context, id int64, page, size int) (model.Import, error)
+}
@@ -0,0 +1,13 @@
+package repo
+
+import (
+	"context"
+	"user-service/api_clients/model"
+)
+
+// OutboxRepo - события, записанные вместе с изменениями и ожидающие публикации
+type OutboxRepo interface {
+	// RelayOutbox передает в publish до limit неопубликованных событий по порядку и помечает их опубликованными,
+	// если publish не вернул ошибку. Возвращает число опубликованных событий.
+	RelayOutbox(ctx context.Context, limit int, publish func([]model.OutboxEvent) error) (int, error)
+}
@@ -0,0 +1,105 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"user-service/api_clients/model"
+	"user-service/pkg/psql"
+	"user-service/repo"
+)
+
+const apiKeyColumns = `id, name, owner, roles, prefix, usage_count, created_at, last_used_at, expires_at, revoked_at`
+
+type APIKeyRepo struct {
+	db *psql.Postgres
+}
+
+func NewAPIKeyRepo(pg *psql.Postgres) *APIKeyRepo {
+	return &APIKeyRepo{
+		db: pg,
+	}
+}
+
+func (r *APIKeyRepo) CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (model.APIKey, error) {
+	query := `
+	INSERT INTO api_keys (name, owner, roles, prefix, key_hash, expires_at) 
+	VALUES ($1, $2, $3, $4, $5, $6) 
+	RETURNING ` + apiKeyColumns
+
+	return scanAPIKey(r.db.Pool.QueryRow(ctx, query, key.Name, key.Owner, key.Roles, key.Prefix, hash, key.ExpiresAt))
+}
+
+func (r *APIKeyRepo) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepo) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	return scanAPIKey(r.db.Pool.QueryRow(ctx, query, hash))
+}
+
+func (r *APIKeyRepo) RotateAPIKey(ctx context.Context, id int64, prefix, hash string) (model.APIKey, error) {
+	query := `
+	UPDATE api_keys SET prefix = $2, key_hash = $3 
+	WHERE id = $1 AND revoked_at IS NULL 
+	RETURNING ` + apiKeyColumns
+
+	return scanAPIKey(r.db.Pool.QueryRow(ctx, query, id, prefix, hash))
+}
+
+func (r *APIKeyRepo) RevokeAPIKey(ctx context.Context, id int64) error {
+	query := `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := r.db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return repo.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepo) AddAPIKeyUsage(ctx context.Context, usage []model.APIKeyUsage) error {
+	query := `
+	UPDATE api_keys 
+	SET usage_count = usage_count + $2, last_used_at = GREATEST(last_used_at, $3) 
+	WHERE id = $1`
+
+	batch := &pgx.Batch{}
+	for _, u := range usage {
+		batch.Queue(query, u.ID, u.Count, u.LastUsedAt)
+	}
+	return r.db.Pool.SendBatch(ctx, batch).Close()
+}
+
+func scanAPIKey(row pgx.Row) (model.APIKey, error) {
+	var key model.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Owner, &key.Roles, &key.Prefix, &key.UsageCount,
+		&key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.APIKey{}, repo.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return model.APIKey{}, mapError(err)
+	}
+	return key, nil
+}
@@ -0,0 +1,142 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"user-service/api_clients/model"
+	"user-service/repo"
+)
+
+// batchChunkSize - сколько операций отправляется в базу за один раунд
+const batchChunkSize = 1000
+
+var errUnknownBatchOp = errors.New("unknown batch operation")
+
+// batchSender - общее у пула и транзакции, чтобы одинаково отправлять пакет в обоих случаях
+type batchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// ApplyBatch выполняет операции через pgx.Batch: запросы уходят в базу одним раундом на каждые batchChunkSize операций
+func (r *UserRepo) ApplyBatch(ctx context.Context, ops []model.BatchOperation, atomic bool) ([]repo.BatchOpResult, error) {
+	if atomic {
+		return r.applyAtomic(ctx, ops)
+	}
+	return r.applyPartial(ctx, ops)
+}
+
+// applyAtomic выполняет все операции в одной транзакции, любая ошибка откатывает весь пакет
+func (r *UserRepo) applyAtomic(ctx context.Context, ops []model.BatchOperation) ([]repo.BatchOpResult, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// после Commit откат ничего не делает
+	defer tx.Rollback(ctx)
+
+	results := make([]repo.BatchOpResult, 0, len(ops))
+	for start := 0; start < len(ops); start += batchChunkSize {
+		chunk := ops[start:chunkEnd(start, len(ops))]
+		for i, res := range sendBatch(ctx, tx, chunk) {
+			if res.Err != nil {
+				return nil, &repo.BatchError{Index: start + i, Err: res.Err}
+			}
+			results = append(results, res)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// applyPartial выполняет каждую порцию операций в своей транзакции. Если в порции есть операция,
+// на которой упала база, порция откатывается и выполняется заново по одной операции,
+// чтобы ошибка досталась только ей.
+func (r *UserRepo) applyPartial(ctx context.Context, ops []model.BatchOperation) ([]repo.BatchOpResult, error) {
+	results := make([]repo.BatchOpResult, len(ops))
+	for start := 0; start < len(ops); start += batchChunkSize {
+		chunk := ops[start:chunkEnd(start, len(ops))]
+
+		chunkResults, err := r.applyChunk(ctx, chunk)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			for i := range chunk {
+				chunkResults[i] = sendBatch(ctx, r.db.Pool, chunk[i:i+1])[0]
+			}
+		}
+		copy(results[start:], chunkResults)
+	}
+	return results, nil
+}
+
+func (r *UserRepo) applyChunk(ctx context.Context, chunk []model.BatchOperation) ([]repo.BatchOpResult, error) {
+	results := make([]repo.BatchOpResult, len(chunk))
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return results, err
+	}
+	defer tx.Rollback(ctx)
+
+	results = sendBatch(ctx, tx, chunk)
+	for _, res := range results {
+		if res.Err != nil && !errors.Is(res.Err, repo.ErrUserNotFound) && !errors.Is(res.Err, errUnknownBatchOp) {
+			return results, res.Err
+		}
+	}
+	return results, tx.Commit(ctx)
+}
+
+// sendBatch ставит операции в один pgx.Batch и возвращает результат по каждой из них
+func sendBatch(ctx context.Context, sender batchSender, ops []model.BatchOperation) []repo.BatchOpResult {
+	actor := repo.ActorFromContext(ctx)
+	results := make([]repo.BatchOpResult, len(ops))
+	queued := make([]int, 0, len(ops))
+
+	batch := &pgx.Batch{}
+	for i, op := range ops {
+		u := op.User
+		switch op.Op {
+		case model.BatchCreate:
+			batch.Queue(createUserQuery, u.Name, u.Surname, u.Patronymic, u.Age, u.Gender, u.Nationality, actor.Name, actor.Source, rawInput(u), u.Latin)
+		case model.BatchUpdate:
+			batch.Queue(updateUserQuery, u.Name, u.Surname, u.Patronymic, op.ID, actor.Name, actor.Source, rawInput(u), u.Latin,
+				u.Age, u.Gender, u.Nationality)
+		case model.BatchDelete:
+			batch.Queue(deleteUserQuery, op.ID, actor.Name, actor.Source)
+		default:
+			results[i] = repo.BatchOpResult{ID: op.ID, Err: fmt.Errorf("%w: %q", errUnknownBatchOp, op.Op)}
+			continue
+		}
+		queued = append(queued, i)
+	}
+	if len(queued) == 0 {
+		return results
+	}
+
+	br := sender.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for _, i := range queued {
+		id := ops[i].ID
+		err := br.QueryRow().Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = repo.ErrUserNotFound
+		}
+		results[i] = repo.BatchOpResult{ID: id, Err: err}
+	}
+	return results
+}
+
+func chunkEnd(start, total int) int {
+	if start+batchChunkSize < total {
+		return start + batchChunkSize
+	}
+	return total
+}
@@ -0,0 +1,20 @@
+package pgdb
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"user-service/repo"
+)
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении ограничения уникальности
+const uniqueViolation = "23505"
+
+// mapError переводит ошибки PostgreSQL в ошибки хранилища
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %s", repo.ErrConflict, pgErr.Detail)
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"user-service/api_clients/model"
+	"user-service/repo"
+)
+
+// exportFetchSize - сколько строк за раз читается из курсора при выгрузке
+const exportFetchSize = 1000
+
+// StreamUsers читает пользователей через серверный курсор порциями по exportFetchSize строк,
+// поэтому в памяти одновременно находится не больше одной порции
+func (r *UserRepo) StreamUsers(ctx context.Context, filter repo.UserFilter, fn func(model.User) error) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// транзакция только читает, поэтому всегда откатываем ее
+	defer tx.Rollback(ctx)
+
+	query := `
+	DECLARE users_export NO SCROLL CURSOR FOR 
+	SELECT id, name, surname, COALESCE(patronymic, ''), COALESCE(age, 0), 
+	       COALESCE(gender, ''), COALESCE(nationality, ''), deleted_at 
+	FROM users 
+	WHERE (name LIKE $1 OR lower(latin) LIKE lower($1)) AND ($2 OR deleted_at IS NULL) 
+	ORDER BY id`
+
+	if _, err := tx.Exec(ctx, query, "%"+filter.Name+"%", filter.IncludeDeleted); err != nil {
+		return err
+	}
+
+	// FETCH не принимает параметры, поэтому размер порции подставляется в текст запроса
+	fetch := fmt.Sprintf("FETCH %d FROM users_export", exportFetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var user model.User
+			err = rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.Age,
+				&user.Gender, &user.Nationality, &user.DeletedAt)
+			if err == nil {
+				err = fn(user)
+			}
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			fetched++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if fetched < exportFetchSize {
+			return nil
+		}
+	}
+}
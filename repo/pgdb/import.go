@@ -0,0 +1,98 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"user-service/api_clients/model"
+	"user-service/pkg/psql"
+	"user-service/repo"
+)
+
+type ImportRepo struct {
+	db *psql.Postgres
+}
+
+func NewImportRepo(pg *psql.Postgres) *ImportRepo {
+	return &ImportRepo{
+		db: pg,
+	}
+}
+
+func (r *ImportRepo) CreateImport(ctx context.Context, imp model.Import) (int64, error) {
+	query := `
+	INSERT INTO imports (filename, format, mode, status) 
+	VALUES ($1, $2, $3, $4) 
+	RETURNING id`
+
+	var id int64
+	err := r.db.Pool.QueryRow(ctx, query, imp.Filename, imp.Format, imp.Mode, imp.Status).Scan(&id)
+	return id, err
+}
+
+func (r *ImportRepo) UpdateImport(ctx context.Context, imp model.Import) error {
+	query := `
+	UPDATE imports 
+	SET status = $2, processed_rows = $3, failed_rows = $4, error = NULLIF($5, ''), finished_at = $6 
+	WHERE id = $1`
+
+	result, err := r.db.Pool.Exec(ctx, query, imp.ID, imp.Status, imp.ProcessedRows, imp.FailedRows, imp.Error, imp.FinishedAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return repo.ErrImportNotFound
+	}
+	return nil
+}
+
+func (r *ImportRepo) AddImportErrors(ctx context.Context, importID int64, rowErrors []model.ImportRowError) error {
+	query := `
+	INSERT INTO import_errors (import_id, row_number, error, raw) 
+	VALUES ($1, $2, $3, $4)`
+
+	batch := &pgx.Batch{}
+	for _, rowErr := range rowErrors {
+		batch.Queue(query, importID, rowErr.Row, rowErr.Error, rowErr.Raw)
+	}
+	return r.db.Pool.SendBatch(ctx, batch).Close()
+}
+
+func (r *ImportRepo) GetImport(ctx context.Context, id int64, page, size int) (model.Import, error) {
+	query := `
+	SELECT id, filename, format, mode, status, processed_rows, failed_rows, COALESCE(error, ''), created_at, finished_at 
+	FROM imports 
+	WHERE id = $1`
+
+	var imp model.Import
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&imp.ID, &imp.Filename, &imp.Format, &imp.Mode, &imp.Status,
+		&imp.ProcessedRows, &imp.FailedRows, &imp.Error, &imp.CreatedAt, &imp.FinishedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Import{}, repo.ErrImportNotFound
+	}
+	if err != nil {
+		return model.Import{}, err
+	}
+
+	errorsQuery := `
+	SELECT row_number, error, COALESCE(raw, '') 
+	FROM import_errors 
+	WHERE import_id = $1 
+	ORDER BY row_number 
+	LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Pool.Query(ctx, errorsQuery, id, size, (page-1)*size)
+	if err != nil {
+		return model.Import{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rowErr model.ImportRowError
+		if err := rows.Scan(&rowErr.Row, &rowErr.Error, &rowErr.Raw); err != nil {
+			return model.Import{}, err
+		}
+		imp.Errors = append(imp.Errors, rowErr)
+	}
+	return imp, rows.Err()
+}
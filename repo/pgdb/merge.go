@@ -0,0 +1,150 @@
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"user-service/api_clients/model"
+	"user-service/repo"
+)
+
+// FindDuplicateCandidates отбирает активных пользователей с той же первой буквой фамилии в исходном написании или латиницей
+func (r *UserRepo) FindDuplicateCandidates(ctx context.Context, filter repo.DuplicateFilter) ([]model.User, error) {
+	query := `
+	SELECT id, name, surname, COALESCE(patronymic, ''), COALESCE(age, 0),
+	       COALESCE(gender, ''), COALESCE(nationality, ''), COALESCE(latin, '')
+	FROM users
+	WHERE id <> $1 AND deleted_at IS NULL
+	  AND (lower(left(surname, 1)) = ANY($2) OR lower(left(latin, 1)) = ANY($2))
+	ORDER BY id
+	LIMIT $3`
+
+	rows, err := r.db.Pool.Query(ctx, query, filter.ExcludeID, filter.SurnamePrefixes, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var user model.User
+		err = rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.Age,
+			&user.Gender, &user.Nationality, &user.Latin)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// MergeUsers сливает дубликаты в одной транзакции: блокирует все записи, заполняет пустые поля
+// сохраняемого пользователя из дубликатов по возрастанию id, мягко удаляет дубликаты и пишет
+// записи merge в журнал сохраняемого пользователя и каждого дубликата и событие в outbox.
+// Дубликаты можно восстановить, пока их не удалит задача хранения.
+func (r *UserRepo) MergeUsers(ctx context.Context, survivorID int, ids []int) (model.User, error) {
+	actor := repo.ActorFromContext(ctx)
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return model.User{}, err
+	}
+	// после Commit откат ничего не делает
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+	SELECT id, name, surname, COALESCE(patronymic, ''), COALESCE(age, 0),
+	       COALESCE(gender, ''), COALESCE(nationality, ''), to_jsonb(u)
+	FROM users u
+	WHERE id = ANY($1) AND deleted_at IS NULL
+	ORDER BY id
+	FOR UPDATE`, append([]int{survivorID}, ids...))
+	if err != nil {
+		return model.User{}, err
+	}
+	users := make(map[int]model.User)
+	snapshots := make(map[int]json.RawMessage)
+	for rows.Next() {
+		var user model.User
+		var snapshot json.RawMessage
+		err = rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.Age,
+			&user.Gender, &user.Nationality, &snapshot)
+		if err != nil {
+			rows.Close()
+			return model.User{}, err
+		}
+		users[user.ID] = user
+		snapshots[user.ID] = snapshot
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.User{}, err
+	}
+
+	survivor, ok := users[survivorID]
+	if !ok {
+		return model.User{}, repo.ErrUserNotFound
+	}
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+	merged := make([]json.RawMessage, 0, len(sorted))
+	for _, id := range sorted {
+		dup, ok := users[id]
+		if !ok {
+			return model.User{}, repo.ErrUserNotFound
+		}
+		survivor.MergeFrom(dup)
+		merged = append(merged, snapshots[id])
+	}
+
+	var after json.RawMessage
+	err = tx.QueryRow(ctx, `
+	UPDATE users u
+	SET patronymic = NULLIF($2, ''), age = NULLIF($3, 0), gender = NULLIF($4, ''), nationality = NULLIF($5, '')
+	WHERE id = $1
+	RETURNING to_jsonb(u)`,
+		survivor.ID, survivor.Patronymic, survivor.Age, survivor.Gender, survivor.Nationality).Scan(&after)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	// дубликаты удаляются мягко и сохраняют свою историю, в журнал каждого пишется, куда он слит
+	_, err = tx.Exec(ctx, `
+	WITH before AS (
+		SELECT * FROM users WHERE id = ANY($1)
+	), upd AS (
+		UPDATE users u SET deleted_at = now() FROM before b WHERE u.id = b.id RETURNING u.*
+	)
+	INSERT INTO user_audit (user_id, actor, source, operation, before, after, details)
+	SELECT upd.id, $2, $3, 'merge', to_jsonb(b), to_jsonb(upd), jsonb_build_object('merged_into', $4::int)
+	FROM upd JOIN before b ON b.id = upd.id`,
+		sorted, actor.Name, actor.Source, survivor.ID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	details, err := json.Marshal(map[string]interface{}{"merged_ids": sorted, "merged": merged})
+	if err != nil {
+		return model.User{}, err
+	}
+	_, err = tx.Exec(ctx, `
+	INSERT INTO user_audit (user_id, actor, source, operation, before, after, details)
+	VALUES ($1, $2, $3, 'merge', $4, $5, $6)`,
+		survivor.ID, actor.Name, actor.Source, string(snapshots[survivor.ID]), string(after), string(details))
+	if err != nil {
+		return model.User{}, err
+	}
+
+	payload, err := json.Marshal(model.UserMerged{SurvivorID: survivor.ID, MergedIDs: sorted, User: survivor})
+	if err != nil {
+		return model.User{}, err
+	}
+	if err := insertOutbox(ctx, tx, "user", survivor.ID, model.EventUserMerged, payload); err != nil {
+		return model.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.User{}, err
+	}
+	return survivor, nil
+}
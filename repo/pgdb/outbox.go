@@ -0,0 +1,88 @@
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"user-service/api_clients/model"
+	"user-service/pkg/logger"
+	"user-service/pkg/psql"
+)
+
+type OutboxRepo struct {
+	db *psql.Postgres
+}
+
+func NewOutboxRepo(pg *psql.Postgres) *OutboxRepo {
+	return &OutboxRepo{db: pg}
+}
+
+// insertOutbox записывает событие в outbox, вызывается внутри транзакции изменения. Вместе с событием
+// сохраняются контекст трассировки и идентификатор запроса из ctx, relay опубликует событие с ними.
+func insertOutbox(ctx context.Context, tx pgx.Tx, aggregate string, aggregateID int, eventType string, payload []byte) error {
+	headers := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+	if id := logger.RequestID(ctx); id != "" {
+		headers[logger.HeaderRequestID] = id
+	}
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO outbox (aggregate, aggregate_id, event_type, payload, headers)
+	VALUES ($1, $2, $3, $4, $5)`, aggregate, aggregateID, eventType, string(payload), string(headersJSON))
+	return err
+}
+
+// RelayOutbox выбирает события с FOR UPDATE SKIP LOCKED, поэтому несколько экземпляров сервиса
+// публикуют разные события, а при ошибке publish транзакция откатывается и события остаются в очереди
+func (r *OutboxRepo) RelayOutbox(ctx context.Context, limit int, publish func([]model.OutboxEvent) error) (int, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// после Commit откат ничего не делает
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+	SELECT id, aggregate, aggregate_id, event_type, payload, headers, created_at
+	FROM outbox
+	WHERE published_at IS NULL
+	ORDER BY id
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var events []model.OutboxEvent
+	var ids []int64
+	for rows.Next() {
+		var event model.OutboxEvent
+		err = rows.Scan(&event.ID, &event.Aggregate, &event.AggregateID, &event.Type, &event.Payload, &event.Headers, &event.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, event)
+		ids = append(ids, event.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE outbox SET published_at = now() WHERE id = ANY($1)`, ids); err != nil {
+		return 0, err
+	}
+	return len(events), tx.Commit(ctx)
+}
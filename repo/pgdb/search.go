@@ -0,0 +1,55 @@
+package pgdb
+
+import (
+	"context"
+	"strconv"
+	"user-service/api_clients/model"
+	"user-service/repo"
+)
+
+// fioExpr - полное ФИО, по этому выражению построен индекс users_fio_trgm_idx
+const fioExpr = `lower(surname || ' ' || name || ' ' || COALESCE(patronymic, ''))`
+
+// SearchUsers ищет по операторам pg_trgm, которые используют GIN-индексы. Запрос сравнивается
+// через word_similarity с наиболее похожей частью ФИО, поэтому одно слово (только фамилия)
+// находит пользователя, хотя с полным ФИО его сходство ниже порога. Порог задается для транзакции,
+// поэтому не влияет на другие запросы соединения.
+func (r *UserRepo) SearchUsers(ctx context.Context, query repo.SearchQuery) ([]model.SearchResult, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// транзакция только читает, поэтому всегда откатывается
+	defer tx.Rollback(ctx)
+
+	threshold := strconv.FormatFloat(query.Threshold, 'f', -1, 64)
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, threshold); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+	SELECT id, name, surname, COALESCE(patronymic, ''), COALESCE(age, 0),
+	       COALESCE(gender, ''), COALESCE(nationality, ''), COALESCE(latin, ''),
+	       greatest(word_similarity($1, `+fioExpr+`), COALESCE(word_similarity($1, lower(latin)), 0)) AS score
+	FROM users
+	WHERE deleted_at IS NULL AND ($1 <% `+fioExpr+` OR $1 <% lower(latin))
+	ORDER BY score DESC, id
+	LIMIT $2 OFFSET $3`, query.Text, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]model.SearchResult, 0)
+	for rows.Next() {
+		var result model.SearchResult
+		user := &result.User
+		err = rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.Age,
+			&user.Gender, &user.Nationality, &user.Latin, &result.Score)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
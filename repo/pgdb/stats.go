@@ -0,0 +1,104 @@
+package pgdb
+
+import (
+	"context"
+	"user-service/api_clients/model"
+	"user-service/repo"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// statsFilter - те же условия, что у списка пользователей: $1 - шаблон имени, $2 - включать удаленных
+const statsFilter = `(name LIKE $1 OR lower(latin) LIKE lower($1)) AND ($2 OR deleted_at IS NULL)`
+
+// UserStats считает агрегаты в одной транзакции REPEATABLE READ, поэтому все разбивки
+// согласованы между собой. Пустые пол, национальность и нулевой возраст считаются неизвестными.
+func (r *UserRepo) UserStats(ctx context.Context, filter repo.UserFilter, bucketWidth int) (model.UserStats, error) {
+	tx, err := r.db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return model.UserStats{}, err
+	}
+	// транзакция только читает, поэтому всегда откатывается
+	defer tx.Rollback(ctx)
+
+	pattern := "%" + filter.Name + "%"
+	stats := model.UserStats{
+		ByGender:            make(map[string]int),
+		ByNationality:       make(map[string]int),
+		ByAge:               make([]model.AgeBucket, 0),
+		BucketWidth:         bucketWidth,
+		GenderByNationality: make(map[string]map[string]int),
+	}
+
+	// Итог, разбивки по полу и национальности и их пересечение одним проходом по таблице
+	rows, err := tx.Query(ctx, `
+	SELECT COALESCE(gender_key, $3), COALESCE(nationality_key, $3),
+	       grouping(gender_key), grouping(nationality_key), count(*)
+	FROM (
+		SELECT NULLIF(gender, '') AS gender_key, NULLIF(nationality, '') AS nationality_key
+		FROM users
+		WHERE `+statsFilter+`
+	) u
+	GROUP BY GROUPING SETS ((gender_key, nationality_key), (gender_key), (nationality_key), ())`,
+		pattern, filter.IncludeDeleted, model.StatsUnknown)
+	if err != nil {
+		return model.UserStats{}, err
+	}
+	for rows.Next() {
+		var gender, nationality string
+		var noGender, noNationality, count int
+		if err := rows.Scan(&gender, &nationality, &noGender, &noNationality, &count); err != nil {
+			rows.Close()
+			return model.UserStats{}, err
+		}
+		switch {
+		case noGender == 1 && noNationality == 1:
+			stats.Total = count
+		case noNationality == 1:
+			stats.ByGender[gender] = count
+		case noGender == 1:
+			stats.ByNationality[nationality] = count
+		default:
+			if stats.GenderByNationality[gender] == nil {
+				stats.GenderByNationality[gender] = make(map[string]int)
+			}
+			stats.GenderByNationality[gender][nationality] = count
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.UserStats{}, err
+	}
+
+	rows, err = tx.Query(ctx, `
+	SELECT age / $3 * $3 AS bucket, count(*)
+	FROM users
+	WHERE `+statsFilter+` AND age > 0
+	GROUP BY bucket
+	ORDER BY bucket`, pattern, filter.IncludeDeleted, bucketWidth)
+	if err != nil {
+		return model.UserStats{}, err
+	}
+	for rows.Next() {
+		var bucket model.AgeBucket
+		if err := rows.Scan(&bucket.From, &bucket.Count); err != nil {
+			rows.Close()
+			return model.UserStats{}, err
+		}
+		bucket.To = bucket.From + bucketWidth - 1
+		stats.ByAge = append(stats.ByAge, bucket)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.UserStats{}, err
+	}
+
+	err = tx.QueryRow(ctx, `
+	SELECT avg(age)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY age)
+	FROM users
+	WHERE `+statsFilter+` AND age > 0`, pattern, filter.IncludeDeleted).Scan(&stats.MeanAge, &stats.MedianAge)
+	if err != nil {
+		return model.UserStats{}, err
+	}
+	return stats, nil
+}
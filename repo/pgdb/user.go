@@ -2,10 +2,58 @@ package pgdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"strings"
+	"time"
 	"user-service/api_clients/model"
 	"user-service/pkg/psql"
+	"user-service/repo"
+)
+
+// Запросы, которые используются и поштучно, и в пакетной обработке
+const (
+	// параметры: name, surname, patronymic, age, gender, nationality, actor, source, raw_input, latin; пустые значения сохраняются как NULL
+	createUserQuery = `
+	WITH ins AS (
+		INSERT INTO users (name, surname, patronymic, age, gender, nationality, raw_input, latin)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), NULLIF($5, ''), NULLIF($6, ''), $9, NULLIF($10, ''))
+		RETURNING *
+	), audit AS (
+		INSERT INTO user_audit (user_id, actor, source, operation, after)
+		SELECT id, $7, $8, 'create', to_jsonb(ins) FROM ins
+	)
+	SELECT id FROM ins`
+
+	// параметры: name, surname, patronymic, id, actor, source, raw_input, latin, age, gender, nationality;
+	// PUT заменяет пользователя целиком, пустые возраст, пол и национальность сохраняются как NULL
+	updateUserQuery = `
+	WITH before AS (
+		SELECT * FROM users WHERE id = $4 AND deleted_at IS NULL FOR UPDATE
+	), upd AS (
+		UPDATE users u 
+		SET name = $1, surname = $2, patronymic = $3, raw_input = $7, latin = NULLIF($8, ''),
+		    age = NULLIF($9, 0), gender = NULLIF($10, ''), nationality = NULLIF($11, '')
+		FROM before b WHERE u.id = b.id 
+		RETURNING u.*
+	), audit AS (
+		INSERT INTO user_audit (user_id, actor, source, operation, before, after)
+		SELECT upd.id, $5, $6, 'update', to_jsonb(b), to_jsonb(upd) FROM upd JOIN before b ON b.id = upd.id
+	)
+	SELECT id FROM upd`
+
+	// параметры: id, actor, source
+	deleteUserQuery = `
+	WITH before AS (
+		SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	), upd AS (
+		UPDATE users u SET deleted_at = now() FROM before b WHERE u.id = b.id RETURNING u.*
+	), audit AS (
+		INSERT INTO user_audit (user_id, actor, source, operation, before, after)
+		SELECT upd.id, $2, $3, 'delete', to_jsonb(b), to_jsonb(upd) FROM upd JOIN before b ON b.id = upd.id
+	)
+	SELECT id FROM upd`
 )
 
 type UserRepo struct {
@@ -26,14 +74,22 @@ type User struct {
 }
 
 func (ur *UserRepo) Save(ctx context.Context, user model.User) error {
+	actor := repo.ActorFromContext(ctx)
 	query := `
-		INSERT INTO users (name, surname, patronymic, age, gender, nationality)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id
+		WITH ins AS (
+			INSERT INTO users (name, surname, patronymic, age, gender, nationality, raw_input, latin)
+			VALUES ($1, $2, $3, $4, $5, $6, $9, NULLIF($10, ''))
+			RETURNING *
+		), audit AS (
+			INSERT INTO user_audit (user_id, actor, source, operation, after)
+			SELECT id, $7, $8, 'save', to_jsonb(ins) FROM ins
+		)
+		SELECT id FROM ins
 	`
 
 	var id int
-	err := ur.db.Pool.QueryRow(ctx, query, user.Name, user.Surname, user.Patronymic, user.Age, user.Gender, user.Nationality).Scan(&id)
+	err := ur.db.Pool.QueryRow(ctx, query, user.Name, user.Surname, user.Patronymic, user.Age, user.Gender, user.Nationality,
+		actor.Name, actor.Source, rawInput(user), user.Latin).Scan(&id)
 	if err != nil {
 		return err
 	}
@@ -43,17 +99,17 @@ func (ur *UserRepo) Save(ctx context.Context, user model.User) error {
 	return nil
 }
 
-func (r *UserRepo) GetUsers(page, size int, filter string) ([]model.User, error) {
+// GetUsers возвращает страницу пользователей, удаленные попадают в выборку только при filter.IncludeDeleted
+func (r *UserRepo) GetUsers(ctx context.Context, page, size int, filter repo.UserFilter) ([]model.User, error) {
 	query := `
-	SELECT id, name, surname, patronymic 
+	SELECT id, name, surname, patronymic, deleted_at 
 	FROM users 
-	WHERE name LIKE $1 
+	WHERE (name LIKE $1 OR lower(latin) LIKE lower($1)) AND ($4 OR deleted_at IS NULL) 
 	LIMIT $2 OFFSET $3`
 
-	filter = "%" + filter + "%"
 	offset := (page - 1) * size
 
-	rows, err := r.db.Pool.Query(context.Background(), query, filter, size, offset)
+	rows, err := r.db.Pool.Query(ctx, query, "%"+filter.Name+"%", size, offset, filter.IncludeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +118,7 @@ func (r *UserRepo) GetUsers(page, size int, filter string) ([]model.User, error)
 	var users []model.User
 	for rows.Next() {
 		var user model.User
-		err = rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic)
+		err = rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic, &user.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -72,7 +128,24 @@ func (r *UserRepo) GetUsers(page, size int, filter string) ([]model.User, error)
 	return users, nil
 }
 
-func (r *UserRepo) AddUser(user model.User) (int, error) {
+// GetUser возвращает пользователя по id, в том числе мягко удаленного
+func (r *UserRepo) GetUser(ctx context.Context, id int) (model.User, error) {
+	query := `
+	SELECT id, name, surname, COALESCE(patronymic, ''), COALESCE(age, 0), 
+	       COALESCE(gender, ''), COALESCE(nationality, ''), deleted_at, raw_input, COALESCE(latin, '') 
+	FROM users 
+	WHERE id = $1`
+
+	var user model.User
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(&user.ID, &user.Name, &user.Surname, &user.Patronymic,
+		&user.Age, &user.Gender, &user.Nationality, &user.DeletedAt, &user.RawInput, &user.Latin)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.User{}, repo.ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *UserRepo) AddUser(ctx context.Context, user model.User) (int, error) {
 	fields := []string{}
 	values := []interface{}{}
 	placeholders := []string{}
@@ -102,50 +175,199 @@ func (r *UserRepo) AddUser(user model.User) (int, error) {
 		values = append(values, user.Nationality)
 	}
 
+	if len(user.RawInput) > 0 {
+		fields = append(fields, "raw_input")
+		values = append(values, string(user.RawInput))
+	}
+
+	if user.Latin != "" {
+		fields = append(fields, "latin")
+		values = append(values, user.Latin)
+	}
+
 	// Генерация плейсхолдеров для SQL-запроса
 	for i := range values {
 		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
 	}
 
+	// Автор изменения идет последними параметрами
+	actor := repo.ActorFromContext(ctx)
+	values = append(values, actor.Name, actor.Source)
+
 	query := fmt.Sprintf(`
-        INSERT INTO users (%s)
-        VALUES (%s)
-        RETURNING id`,
+        WITH ins AS (
+            INSERT INTO users (%s)
+            VALUES (%s)
+            RETURNING *
+        ), audit AS (
+            INSERT INTO user_audit (user_id, actor, source, operation, after)
+            SELECT id, $%d, $%d, 'create', to_jsonb(ins) FROM ins
+        )
+        SELECT id FROM ins`,
 		strings.Join(fields, ", "),
 		strings.Join(placeholders, ", "),
+		len(values)-1, len(values),
 	)
 
 	var id int
-	err := r.db.Pool.QueryRow(context.Background(), query, values...).Scan(&id)
+	err := r.db.Pool.QueryRow(ctx, query, values...).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
 	return id, nil
 }
 
-func (r *UserRepo) DeleteUser(id int) error {
+// DeleteUser мягко удаляет пользователя, проставляя deleted_at
+func (r *UserRepo) DeleteUser(ctx context.Context, id int) error {
+	return r.execAudited(ctx, deleteUserQuery, id)
+}
+
+// RestoreUser снимает отметку об удалении
+func (r *UserRepo) RestoreUser(ctx context.Context, id int) error {
 	query := `
-	DELETE FROM users 
-	WHERE id = $1`
+	WITH before AS (
+		SELECT * FROM users WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE
+	), upd AS (
+		UPDATE users u SET deleted_at = NULL FROM before b WHERE u.id = b.id RETURNING u.*
+	), audit AS (
+		INSERT INTO user_audit (user_id, actor, source, operation, before, after)
+		SELECT upd.id, $2, $3, 'restore', to_jsonb(b), to_jsonb(upd) FROM upd JOIN before b ON b.id = upd.id
+	)
+	SELECT id FROM upd`
 
-	_, err := r.db.Pool.Exec(context.Background(), query, id)
-	return err
+	return r.execAudited(ctx, query, id)
 }
 
-func (r *UserRepo) UpdateUser(user model.User) error {
+// PurgeUser окончательно удаляет пользователя из таблицы, журнал аудита при этом сохраняется
+func (r *UserRepo) PurgeUser(ctx context.Context, id int) error {
 	query := `
-	UPDATE users 
-	SET name = $1, surname = $2, patronymic = $3 
-	WHERE id = $4`
+	WITH del AS (
+		DELETE FROM users WHERE id = $1 RETURNING *
+	), audit AS (
+		INSERT INTO user_audit (user_id, actor, source, operation, before)
+		SELECT id, $2, $3, 'purge', to_jsonb(del) FROM del
+	)
+	SELECT id FROM del`
+
+	return r.execAudited(ctx, query, id)
+}
 
-	result, err := r.db.Pool.Exec(context.Background(), query, user.Name, user.Surname, user.Patronymic, user.ID)
+// PurgeDeleted окончательно удаляет пользователей, мягко удаленных раньше before, и возвращает их id
+func (r *UserRepo) PurgeDeleted(ctx context.Context, before time.Time) ([]int, error) {
+	actor := repo.ActorFromContext(ctx)
+	query := `
+	WITH del AS (
+		DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING *
+	), audit AS (
+		INSERT INTO user_audit (user_id, actor, source, operation, before)
+		SELECT id, $2, $3, 'purge', to_jsonb(del) FROM del
+	)
+	SELECT id FROM del`
+
+	rows, err := r.db.Pool.Query(ctx, query, before, actor.Name, actor.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *UserRepo) UpdateUser(ctx context.Context, user model.User) error {
+	actor := repo.ActorFromContext(ctx)
+	result, err := r.db.Pool.Exec(ctx, updateUserQuery, user.Name, user.Surname, user.Patronymic, user.ID, actor.Name, actor.Source, rawInput(user), user.Latin,
+		user.Age, user.Gender, user.Nationality)
 	if err != nil {
 		return err
 	}
 	// Проверка количества обновленных строк
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("User with ID %d not found", user.ID)
+		return repo.ErrUserNotFound
 	}
 	return nil
 }
+
+// GetUserHistory возвращает журнал изменений пользователя, начиная с последних. В журнал входит история
+// пользователей, слитых в него (в том числе через промежуточное слияние): их записи merge хранят merged_into.
+// Слитый пользователь, восстановленный после слияния, в историю больше не входит.
+func (r *UserRepo) GetUserHistory(ctx context.Context, id, page, size int) ([]model.AuditRecord, error) {
+	query := `
+	WITH RECURSIVE merged (user_id) AS (
+		SELECT $1::int
+		UNION
+		SELECT a.user_id
+		FROM user_audit a JOIN merged m ON (a.details->>'merged_into')::int = m.user_id
+		WHERE a.operation = 'merge'
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_audit r WHERE r.user_id = a.user_id AND r.operation = 'restore' AND r.id > a.id
+		  )
+	)
+	SELECT id, user_id, actor, source, operation, before, after, details, created_at
+	FROM user_audit
+	WHERE user_id IN (SELECT user_id FROM merged)
+	ORDER BY id DESC
+	LIMIT $2 OFFSET $3`
+
+	offset := (page - 1) * size
+
+	rows, err := r.db.Pool.Query(ctx, query, id, size, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []model.AuditRecord{}
+	for rows.Next() {
+		var record model.AuditRecord
+		err = rows.Scan(&record.ID, &record.UserID, &record.Actor, &record.Source, &record.Operation,
+			&record.Before, &record.After, &record.Details, &record.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// RecordAccessDenied записывает отказ в доступе с операцией denied, подробности лежат в details
+func (r *UserRepo) RecordAccessDenied(ctx context.Context, denial model.AccessDenial) error {
+	query := `
+	INSERT INTO user_audit (user_id, actor, source, operation, details) 
+	VALUES ($1, $2, $3, 'denied', $4)`
+
+	actor := repo.ActorFromContext(ctx)
+	_, err := r.db.Pool.Exec(ctx, query, denial.UserID, actor.Name, actor.Source, denial)
+	return err
+}
+
+// execAudited выполняет изменение одного пользователя с записью в журнал аудита.
+// Запрос принимает id первым параметром, автора и источник вторым и третьим.
+func (r *UserRepo) execAudited(ctx context.Context, query string, id int) error {
+	actor := repo.ActorFromContext(ctx)
+	result, err := r.db.Pool.Exec(ctx, query, id, actor.Name, actor.Source)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return repo.ErrUserNotFound
+	}
+	return nil
+}
+
+// rawInput возвращает исходное ФИО для колонки jsonb, пустое значение сохраняется как NULL
+func rawInput(user model.User) interface{} {
+	if len(user.RawInput) == 0 {
+		return nil
+	}
+	return string(user.RawInput)
+}
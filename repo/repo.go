@@ -2,13 +2,93 @@ package repo
 
 import (
 	"context"
+	"fmt"
+	"time"
 	"user-service/api_clients/model"
 )
 
+// ErrUserNotFound возвращается, если пользователя с указанным id нет (или он уже удален)
+var ErrUserNotFound = fmt.Errorf("user %w", ErrNotFound)
+
+// UserFilter - условия выборки пользователей, общие для списка и выгрузки
+type UserFilter struct {
+	// Name - подстрока имени или, если хранится транслитерация, ФИО латиницей
+	Name string
+	// IncludeDeleted - включать мягко удаленных пользователей
+	IncludeDeleted bool
+}
+
+// BatchOpResult - результат одной операции пакета: id затронутого пользователя или ошибка
+type BatchOpResult struct {
+	ID  int
+	Err error
+}
+
+// BatchError возвращается, когда пакет в транзакционном режиме откатан из-за операции с номером Index
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// UserRepo - хранилище пользователей. Все изменяющие методы пишут в журнал аудита
+// автора из контекста (см. WithActor).
 type UserRepo interface {
 	Save(ctx context.Context, user model.User) error
-	GetUsers(page, size int, filter string) ([]model.User, error)
-	AddUser(user model.User) (int, error)
-	DeleteUser(id int) error
-	UpdateUser(user model.User) error
+	GetUsers(ctx context.Context, page, size int, filter UserFilter) ([]model.User, error)
+	// GetUser возвращает пользователя по id, в том числе мягко удаленного
+	GetUser(ctx context.Context, id int) (model.User, error)
+	// StreamUsers вызывает fn для каждого пользователя, подходящего под фильтр, не загружая выборку в память целиком
+	StreamUsers(ctx context.Context, filter UserFilter, fn func(model.User) error) error
+	AddUser(ctx context.Context, user model.User) (int, error)
+	DeleteUser(ctx context.Context, id int) error
+	UpdateUser(ctx context.Context, user model.User) error
+	RestoreUser(ctx context.Context, id int) error
+	PurgeUser(ctx context.Context, id int) error
+	// PurgeDeleted окончательно удаляет пользователей, мягко удаленных раньше before, и возвращает их id
+	PurgeDeleted(ctx context.Context, before time.Time) ([]int, error)
+	// GetUserHistory возвращает журнал пользователя вместе с журналами слитых в него дубликатов
+	GetUserHistory(ctx context.Context, id, page, size int) ([]model.AuditRecord, error)
+	// RecordAccessDenied записывает в журнал аудита отказ в доступе
+	RecordAccessDenied(ctx context.Context, denial model.AccessDenial) error
+	// ApplyBatch выполняет операции пакетом. При atomic все операции выполняются в одной транзакции
+	// и первая же ошибка откатывает весь пакет, иначе для каждой операции возвращается свой результат.
+	ApplyBatch(ctx context.Context, ops []model.BatchOperation, atomic bool) ([]BatchOpResult, error)
+	// FindDuplicateCandidates возвращает активных пользователей, которые могут оказаться дубликатами
+	FindDuplicateCandidates(ctx context.Context, filter DuplicateFilter) ([]model.User, error)
+	// MergeUsers заполняет пустые поля пользователя survivorID из дубликатов ids, мягко удаляет дубликаты,
+	// отмечая в их журнале merged_into, и записывает событие user.merged в outbox в той же транзакции
+	// (история дубликатов попадает в GetUserHistory пользователя survivorID)
+	MergeUsers(ctx context.Context, survivorID int, ids []int) (model.User, error)
+	// SearchUsers ищет активных пользователей по триграммному сходству запроса с частью полного ФИО или ФИО латиницей,
+	// результаты упорядочены по убыванию сходства
+	SearchUsers(ctx context.Context, query SearchQuery) ([]model.SearchResult, error)
+	// UserStats считает агрегаты по пользователям, подходящим под фильтр, возраст группируется по bucketWidth лет
+	UserStats(ctx context.Context, filter UserFilter, bucketWidth int) (model.UserStats, error)
+}
+
+// SearchQuery - нечеткий поиск по ФИО
+type SearchQuery struct {
+	// Text - запрос в нижнем регистре, слова в любом порядке
+	Text string
+	// Threshold - минимальное сходство от 0 до 1
+	Threshold float64
+	Limit     int
+	Offset    int
+}
+
+// DuplicateFilter - грубый отбор кандидатов в дубликаты, точное сходство считается в сервисе
+type DuplicateFilter struct {
+	// ExcludeID - сам проверяемый пользователь
+	ExcludeID int
+	// SurnamePrefixes - первые буквы фамилии в нижнем регистре, в исходном написании и латиницей
+	SurnamePrefixes []string
+	Limit           int
 }
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"user-service/api_clients/model"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
+)
+
+// Forbidden отвечает 403 на запрос без нужного разрешения и записывает отказ в журнал аудита
+func (f *FIOService) Forbidden(c echo.Context, permission string) error {
+	denial := model.AccessDenial{
+		Permission: permission,
+		Method:     c.Request().Method,
+		Path:       c.Request().URL.Path,
+	}
+	if claims, ok := auth.ClaimsFromContext(c.Request().Context()); ok {
+		denial.Subject = claims.Subject
+	}
+	if id, err := strconv.Atoi(c.Param("id")); err == nil {
+		denial.UserID = &id
+	}
+
+	if err := f.userRepo.RecordAccessDenied(requestContext(c), denial); err != nil {
+		logger.FromContext(c.Request().Context()).Error("Failed to record access denial:", err)
+	}
+
+	return (&Error{Status: http.StatusForbidden, Code: CodeForbidden, Detail: "Forbidden"}).With("permission", permission)
+}
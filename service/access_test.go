@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-service/api_clients/model"
+	"user-service/pkg/auth"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	t.Run("Denial is recorded in audit log", func(t *testing.T) {
+		id := 7
+		mockUserRepo.EXPECT().RecordAccessDenied(gomock.Any(), model.AccessDenial{
+			Subject:    "bob",
+			Permission: auth.PermUsersDelete,
+			Method:     http.MethodDelete,
+			Path:       "/users/7",
+			UserID:     &id,
+		}).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/users/7", nil)
+		req = req.WithContext(auth.WithClaims(req.Context(), auth.Claims{Subject: "bob"}))
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		HTTPErrorHandler(f.Forbidden(c, auth.PermUsersDelete), c)
+
+		if got, want := rec.Code, http.StatusForbidden; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		if !strings.Contains(rec.Body.String(), `"permission":"users:delete"`) {
+			t.Errorf("unexpected body %s", rec.Body.String())
+		}
+	})
+
+	t.Run("Batch with deletes requires delete permission", func(t *testing.T) {
+		mockUserRepo.EXPECT().RecordAccessDenied(gomock.Any(), gomock.Any()).Return(nil)
+
+		body := `[{"op": "delete", "id": 1}]`
+		req := httptest.NewRequest(http.MethodPost, "/users:batch", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req = req.WithContext(auth.WithPermissions(req.Context(), []string{auth.PermUsersWrite}))
+		rec := httptest.NewRecorder()
+
+		handle(e.NewContext(req, rec), f.BatchUsers)
+
+		if got, want := rec.Code, http.StatusForbidden; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"user-service/api_clients/model"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
+	"user-service/repo"
+)
+
+const (
+	// apiKeyPrefix отличает ключи сервиса от других секретов, например при поиске утечек
+	apiKeyPrefix = "usk_"
+	// apiKeyShownPrefix - сколько первых символов ключа хранится открыто, чтобы ключ можно было опознать
+	apiKeyShownPrefix = len(apiKeyPrefix) + 8
+	apiKeyBytes       = 32
+
+	// apiKeyCacheTTL - сколько найденный ключ живет в памяти процесса. Отзыв ключа на другом
+	// экземпляре сервиса начинает действовать здесь не позже чем через это время.
+	apiKeyCacheTTL       = 30 * time.Second
+	apiKeyCacheSize      = 1000
+	apiKeySubjectPrefix  = "apikey:"
+	apiKeyFlushTimeout   = 10 * time.Second
+	defaultAPIKeyFlushIn = time.Minute
+)
+
+var (
+	// ErrInvalidAPIKey возвращается для неизвестного или отозванного ключа
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrExpiredAPIKey возвращается, если срок действия ключа истек
+	ErrExpiredAPIKey = errors.New("api key is expired")
+)
+
+type issueAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner"`
+	Roles     []string   `json:"roles"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IssueAPIKey выпускает ключ. Сам ключ возвращается только в этом ответе, в базе хранится его хэш.
+func (f *FIOService) IssueAPIKey(c echo.Context) error {
+	var req issueAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return InvalidBody(err)
+	}
+	if req.Name == "" || req.Owner == "" {
+		var fields []FieldError
+		if req.Name == "" {
+			fields = append(fields, FieldError{Field: "name", Code: RuleRequired, Message: "is required"})
+		}
+		if req.Owner == "" {
+			fields = append(fields, FieldError{Field: "owner", Code: RuleRequired, Message: "is required"})
+		}
+		return Validation("Both name and owner are required", fields...)
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return Validation("expires_at must be in the future", FieldError{Field: "expires_at", Code: RuleOutOfRange, Message: "must be in the future"})
+	}
+	if req.Roles == nil {
+		req.Roles = []string{}
+	}
+
+	secret := newAPIKey()
+	key, err := f.apiKeyRepo.CreateAPIKey(c.Request().Context(), model.APIKey{
+		Name:      req.Name,
+		Owner:     req.Owner,
+		Roles:     req.Roles,
+		Prefix:    secret[:apiKeyShownPrefix],
+		ExpiresAt: req.ExpiresAt,
+	}, hashAPIKey(secret))
+	if err != nil {
+		return Internal("Failed to create API key", err)
+	}
+
+	logger.FromContext(c.Request().Context()).WithFields(log.Fields{"id": key.ID, "name": key.Name, "owner": key.Owner}).Info("API key issued")
+	return c.JSON(http.StatusCreated, model.IssuedAPIKey{APIKey: key, Key: secret})
+}
+
+// ListAPIKeys возвращает все ключи без секретов
+func (f *FIOService) ListAPIKeys(c echo.Context) error {
+	keys, err := f.apiKeyRepo.ListAPIKeys(c.Request().Context())
+	if err != nil {
+		return Internal("Failed to fetch API keys", err)
+	}
+	return c.JSON(http.StatusOK, keys)
+}
+
+// RotateAPIKey выпускает новый секрет для ключа, старый сразу перестает действовать
+func (f *FIOService) RotateAPIKey(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return InvalidParameter("id")
+	}
+
+	secret := newAPIKey()
+	key, err := f.apiKeyRepo.RotateAPIKey(c.Request().Context(), id, secret[:apiKeyShownPrefix], hashAPIKey(secret))
+	if errors.Is(err, repo.ErrAPIKeyNotFound) {
+		return NotFound("API key not found")
+	}
+	if err != nil {
+		return Internal("Failed to rotate API key", err)
+	}
+
+	f.forgetAPIKeys()
+	logger.FromContext(c.Request().Context()).WithFields(log.Fields{"id": key.ID, "name": key.Name}).Info("API key rotated")
+	return c.JSON(http.StatusOK, model.IssuedAPIKey{APIKey: key, Key: secret})
+}
+
+// RevokeAPIKey отзывает ключ
+func (f *FIOService) RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return InvalidParameter("id")
+	}
+
+	err = f.apiKeyRepo.RevokeAPIKey(c.Request().Context(), id)
+	if errors.Is(err, repo.ErrAPIKeyNotFound) {
+		return NotFound("API key not found")
+	}
+	if err != nil {
+		return Internal("Failed to revoke API key", err)
+	}
+
+	f.forgetAPIKeys()
+	logger.FromContext(c.Request().Context()).WithField("id", id).Info("API key revoked")
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AuthenticateAPIKey проверяет ключ и возвращает данные клиента: subject apikey:<name> и роли ключа.
+// Каждое успешное обращение учитывается в счетчике использования ключа.
+func (f *FIOService) AuthenticateAPIKey(ctx context.Context, secret string) (auth.Claims, error) {
+	key, err := f.lookupAPIKey(ctx, hashAPIKey(secret))
+	if err != nil {
+		return auth.Claims{}, err
+	}
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return auth.Claims{}, ErrExpiredAPIKey
+	}
+
+	f.apiKeyUsage.record(key.ID, time.Now())
+	return auth.Claims{Subject: apiKeySubjectPrefix + key.Name, Roles: key.Roles, APIKeyID: key.ID}, nil
+}
+
+// RunAPIKeyUsageFlush раз в interval сохраняет накопленные счетчики использования ключей.
+// Работает до вызова Stop, перед выходом сохраняет остаток.
+func (f *FIOService) RunAPIKeyUsageFlush(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultAPIKeyFlushIn
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopCh:
+			f.flushAPIKeyUsage()
+			return
+		case <-ticker.C:
+			f.flushAPIKeyUsage()
+		}
+	}
+}
+
+func (f *FIOService) flushAPIKeyUsage() {
+	usage := f.apiKeyUsage.drain()
+	if len(usage) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiKeyFlushTimeout)
+	defer cancel()
+	if err := f.apiKeyRepo.AddAPIKeyUsage(ctx, usage); err != nil {
+		log.Error("Failed to save API key usage:", err)
+	}
+}
+
+// lookupAPIKey ищет ключ сначала в памяти процесса, потом в базе
+func (f *FIOService) lookupAPIKey(ctx context.Context, hash string) (model.APIKey, error) {
+	var key model.APIKey
+	if f.apiKeys != nil {
+		if data, err := f.apiKeys.Get(ctx, hash); err == nil && json.Unmarshal(data, &key) == nil {
+			return key, nil
+		}
+	}
+
+	key, err := f.apiKeyRepo.GetAPIKeyByHash(ctx, hash)
+	if errors.Is(err, repo.ErrAPIKeyNotFound) {
+		return model.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("lookup api key: %w", err)
+	}
+
+	if data, err := json.Marshal(key); err == nil && f.apiKeys != nil {
+		_ = f.apiKeys.Set(ctx, hash, data, apiKeyCacheTTL)
+	}
+	return key, nil
+}
+
+// forgetAPIKeys сбрасывает найденные ключи после ротации или отзыва. Ключи кэшируются по хэшу,
+// поэтому проще сбросить все, чем искать хэш старого секрета.
+func (f *FIOService) forgetAPIKeys() {
+	if f.apiKeys != nil {
+		f.apiKeys.Purge()
+	}
+}
+
+func newAPIKey() string {
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(b)
+}
+
+// hashAPIKey - ключ случайный и длинный, поэтому для хранения достаточно SHA-256 без соли
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyUsage копит число запросов по ключам между сохранениями в базу
+type apiKeyUsage struct {
+	mu     sync.Mutex
+	counts map[int64]*model.APIKeyUsage
+}
+
+func newAPIKeyUsage() *apiKeyUsage {
+	return &apiKeyUsage{
+		counts: make(map[int64]*model.APIKeyUsage),
+	}
+}
+
+func (u *apiKeyUsage) record(id int64, at time.Time) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usage, ok := u.counts[id]
+	if !ok {
+		usage = &model.APIKeyUsage{ID: id}
+		u.counts[id] = usage
+	}
+	usage.Count++
+	usage.LastUsedAt = at
+}
+
+func (u *apiKeyUsage) drain() []model.APIKeyUsage {
+	if u == nil {
+		return nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	usage := make([]model.APIKeyUsage, 0, len(u.counts))
+	for _, c := range u.counts {
+		usage = append(usage, *c)
+	}
+	u.counts = make(map[int64]*model.APIKeyUsage)
+	return usage
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-service/api_clients/model"
+	"user-service/pkg/cache"
+	"user-service/repo"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{apiKeyRepo: mockAPIKeyRepo, apiKeys: cache.NewLRU(10, 0), apiKeyUsage: newAPIKeyUsage()}
+
+	t.Run("Name and owner are required", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewBufferString(`{"name": "billing"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		handle(e.NewContext(req, rec), f.IssueAPIKey)
+
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+
+	var secret string
+	t.Run("Key is issued and stored hashed", func(t *testing.T) {
+		var storedHash string
+		mockAPIKeyRepo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, key model.APIKey, hash string) (model.APIKey, error) {
+				storedHash = hash
+				key.ID = 1
+				return key, nil
+			})
+
+		body := `{"name": "billing", "owner": "billing-team", "roles": ["reader"]}`
+		req := httptest.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		handle(e.NewContext(req, rec), f.IssueAPIKey)
+
+		if got, want := rec.Code, http.StatusCreated; got != want {
+			t.Fatalf("got status %d, wanted %d", got, want)
+		}
+		var issued model.IssuedAPIKey
+		if err := json.Unmarshal(rec.Body.Bytes(), &issued); err != nil {
+			t.Fatal(err)
+		}
+		secret = issued.Key
+		if !strings.HasPrefix(secret, apiKeyPrefix) || !strings.HasPrefix(secret, issued.Prefix) {
+			t.Errorf("unexpected key %q with prefix %q", secret, issued.Prefix)
+		}
+		if storedHash != hashAPIKey(secret) || strings.Contains(storedHash, secret) {
+			t.Error("expected only hash of the key to be stored")
+		}
+	})
+
+	t.Run("Key is authenticated and usage is counted", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashAPIKey(secret)).
+			Return(model.APIKey{ID: 1, Name: "billing", Roles: []string{"reader"}}, nil).Times(1)
+
+		for i := 0; i < 3; i++ {
+			claims, err := f.AuthenticateAPIKey(context.Background(), secret)
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if claims.Subject != "apikey:billing" || claims.APIKeyID != 1 || len(claims.Roles) != 1 {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+		}
+
+		mockAPIKeyRepo.EXPECT().AddAPIKeyUsage(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, usage []model.APIKeyUsage) error {
+				if len(usage) != 1 || usage[0].ID != 1 || usage[0].Count != 3 {
+					t.Errorf("unexpected usage %+v", usage)
+				}
+				return nil
+			})
+		f.flushAPIKeyUsage()
+		// счетчики после сохранения обнуляются
+		f.flushAPIKeyUsage()
+	})
+
+	t.Run("Expired and unknown keys are rejected", func(t *testing.T) {
+		expired := time.Now().Add(-time.Hour)
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashAPIKey("usk_expired")).
+			Return(model.APIKey{ID: 2, ExpiresAt: &expired}, nil)
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashAPIKey("usk_unknown")).
+			Return(model.APIKey{}, repo.ErrAPIKeyNotFound)
+
+		if _, err := f.AuthenticateAPIKey(context.Background(), "usk_expired"); !errors.Is(err, ErrExpiredAPIKey) {
+			t.Errorf("got %v, wanted ErrExpiredAPIKey", err)
+		}
+		if _, err := f.AuthenticateAPIKey(context.Background(), "usk_unknown"); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("got %v, wanted ErrInvalidAPIKey", err)
+		}
+	})
+
+	t.Run("Revoked key is forgotten", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().RevokeAPIKey(gomock.Any(), int64(1)).Return(nil)
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), hashAPIKey(secret)).Return(model.APIKey{}, repo.ErrAPIKeyNotFound)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/api-keys/1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		handle(c, f.RevokeAPIKey)
+
+		if got, want := rec.Code, http.StatusNoContent; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		if _, err := f.AuthenticateAPIKey(context.Background(), secret); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("got %v, wanted ErrInvalidAPIKey after revoke", err)
+		}
+	})
+
+	t.Run("Revoke unknown key", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().RevokeAPIKey(gomock.Any(), int64(5)).Return(repo.ErrAPIKeyNotFound)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/api-keys/5", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("5")
+
+		handle(c, f.RevokeAPIKey)
+
+		if got, want := rec.Code, http.StatusNotFound; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+	"strconv"
+	"user-service/api_clients/model"
+	"user-service/pkg/auth"
+	"user-service/repo"
+)
+
+const (
+	defaultHistoryPage = 1
+	defaultHistorySize = 20
+)
+
+// anonymousActor - автор изменений в журнале аудита, если запрос не аутентифицирован
+const anonymousActor = "anonymous"
+
+// GetUserHistory возвращает журнал изменений пользователя с пагинацией, для каждой записи вычисляется список измененных полей
+func (f *FIOService) GetUserHistory(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return InvalidParameter("id")
+	}
+
+	page, size := defaultHistoryPage, defaultHistorySize
+	if pageStr := c.QueryParam("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return InvalidParameter("page")
+		}
+	}
+	if sizeStr := c.QueryParam("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size < 1 {
+			return InvalidParameter("size")
+		}
+	}
+
+	records, err := f.userRepo.GetUserHistory(c.Request().Context(), id, page, size)
+	if err != nil {
+		return Internal("Failed to fetch user history", err)
+	}
+
+	for i := range records {
+		records[i].Changes = diffJSON(records[i].Before, records[i].After)
+	}
+	return c.JSON(http.StatusOK, records)
+}
+
+// requestContext возвращает контекст запроса с автором изменений для журнала аудита. Автор берется
+// только из проверенных данных клиента: subject токена или имя и id API-ключа, иначе anonymous.
+// Заголовкам запроса не доверяем, иначе любой клиент мог бы записать в журнал чужое имя.
+func requestContext(c echo.Context) context.Context {
+	actor := anonymousActor
+	if claims, ok := auth.ClaimsFromContext(c.Request().Context()); ok {
+		switch {
+		case claims.APIKeyID != 0:
+			// имена ключей не уникальны, id однозначно указывает ключ
+			actor = claims.Subject + "#" + strconv.FormatInt(claims.APIKeyID, 10)
+		case claims.Subject != "":
+			actor = claims.Subject
+		}
+	}
+	return repo.WithActor(c.Request().Context(), repo.Actor{Name: actor, Source: repo.SourceHTTP})
+}
+
+// diffJSON сравнивает два JSON-объекта и возвращает поля, значения которых отличаются
+func diffJSON(before, after json.RawMessage) map[string]model.FieldChange {
+	var oldFields, newFields map[string]interface{}
+	if len(before) > 0 {
+		_ = json.Unmarshal(before, &oldFields)
+	}
+	if len(after) > 0 {
+		_ = json.Unmarshal(after, &newFields)
+	}
+
+	changes := make(map[string]model.FieldChange)
+	for field, newValue := range newFields {
+		oldValue, ok := oldFields[field]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = model.FieldChange{Old: oldValue, New: newValue}
+		}
+	}
+	for field, oldValue := range oldFields {
+		if _, ok := newFields[field]; !ok {
+			changes[field] = model.FieldChange{Old: oldValue, New: nil}
+		}
+	}
+	return changes
+}
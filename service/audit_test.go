@@ -0,0 +1,85 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"user-service/api_clients/model"
+	"user-service/pkg/auth"
+	"user-service/repo"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected map[string]model.FieldChange
+	}{
+		{
+			name:  "Create",
+			after: `{"id": 1, "name": "Ivan"}`,
+			expected: map[string]model.FieldChange{
+				"id":   {Old: nil, New: float64(1)},
+				"name": {Old: nil, New: "Ivan"},
+			},
+		},
+		{
+			name:   "Update",
+			before: `{"id": 1, "name": "Ivan", "age": 30}`,
+			after:  `{"id": 1, "name": "Petr", "age": 30}`,
+			expected: map[string]model.FieldChange{
+				"name": {Old: "Ivan", New: "Petr"},
+			},
+		},
+		{
+			name:   "Purge",
+			before: `{"id": 1}`,
+			expected: map[string]model.FieldChange{
+				"id": {Old: float64(1), New: nil},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := diffJSON(json.RawMessage(test.before), json.RawMessage(test.after))
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("got %v, wanted %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestRequestContextActor(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   *auth.Claims
+		expected string
+	}{
+		{name: "Token subject", claims: &auth.Claims{Subject: "alice"}, expected: "alice"},
+		{name: "API key", claims: &auth.Claims{Subject: "apikey:import", APIKeyID: 7}, expected: "apikey:import#7"},
+		{name: "Unauthenticated", expected: anonymousActor},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/users/1", nil)
+			// заголовок клиента не должен попасть в журнал аудита
+			req.Header.Set("X-Actor", "mallory")
+			if test.claims != nil {
+				req = req.WithContext(auth.WithClaims(req.Context(), *test.claims))
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			actor := repo.ActorFromContext(requestContext(c))
+			if actor.Name != test.expected || actor.Source != repo.SourceHTTP {
+				t.Errorf("got actor %+v, wanted %s", actor, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"strings"
+	"user-service/api_clients/model"
+	"user-service/pkg/auth"
+	"user-service/pkg/logger"
+	"user-service/repo"
+)
+
+// MaxBatchSize - максимальное число операций в одном пакетном запросе
+const MaxBatchSize = 50000
+
+// Режимы пакетной обработки
+const (
+	BatchModeAtomic  = "atomic"
+	BatchModePartial = "partial"
+)
+
+const mimeNDJSON = "application/x-ndjson"
+
+var errBatchTooLarge = fmt.Errorf("batch exceeds %d operations", MaxBatchSize)
+
+// BatchUsers выполняет пакет операций create/update/delete. Тело - JSON-массив операций
+// или поток NDJSON (Content-Type: application/x-ndjson). В режиме atomic (по умолчанию) пакет
+// выполняется целиком или не выполняется совсем, в режиме partial возвращается результат каждой операции.
+func (f *FIOService) BatchUsers(c echo.Context) error {
+	mode := c.QueryParam("mode")
+	if mode == "" {
+		mode = BatchModeAtomic
+	}
+	if mode != BatchModeAtomic && mode != BatchModePartial {
+		return InvalidParameter("mode")
+	}
+	atomic := mode == BatchModeAtomic
+
+	ops, err := decodeBatch(c.Request())
+	if errors.Is(err, errBatchTooLarge) {
+		return &Error{Status: http.StatusRequestEntityTooLarge, Code: CodePayloadTooLarge, Detail: err.Error()}
+	}
+	if err != nil {
+		return InvalidBody(err)
+	}
+	if len(ops) == 0 {
+		return Validation("Batch is empty")
+	}
+
+	// Удаление в пакете требует того же разрешения, что и DELETE /users/:id
+	if hasBatchDeletes(ops) && !auth.Can(c.Request().Context(), auth.PermUsersDelete) {
+		return f.Forbidden(c, auth.PermUsersDelete)
+	}
+
+	// Некорректные операции отсекаем до похода в базу
+	results := make([]model.BatchResult, len(ops))
+	valid := make([]model.BatchOperation, 0, len(ops))
+	validIdx := make([]int, 0, len(ops))
+	var invalid []model.BatchResult
+	for i, op := range ops {
+		results[i] = model.BatchResult{Index: i, Op: op.Op, ID: op.ID}
+		if op.Op == model.BatchCreate || op.Op == model.BatchUpdate {
+			f.normalizeUser(&op.User)
+		}
+		if err := validateBatchOp(op); err != nil {
+			results[i].Status = http.StatusBadRequest
+			results[i].Error = err.Error()
+			invalid = append(invalid, results[i])
+			continue
+		}
+		valid = append(valid, op)
+		validIdx = append(validIdx, i)
+	}
+	if atomic && len(invalid) > 0 {
+		return Validation("Batch contains invalid operations").With("results", invalid)
+	}
+
+	repoResults, err := f.userRepo.ApplyBatch(requestContext(c), valid, atomic)
+	var batchErr *repo.BatchError
+	if errors.As(err, &batchErr) && errors.Is(batchErr.Err, repo.ErrUserNotFound) {
+		failed := results[validIdx[batchErr.Index]]
+		failed.Status = http.StatusNotFound
+		failed.Error = "User not found"
+		return (&Error{Status: http.StatusUnprocessableEntity, Code: CodeUnprocessable, Detail: "Batch rolled back"}).
+			With("results", []model.BatchResult{failed})
+	}
+	if err != nil {
+		return Internal("Failed to apply batch", err)
+	}
+
+	var changed []int
+	for j, res := range repoResults {
+		result := &results[validIdx[j]]
+		result.ID = res.ID
+		switch {
+		case res.Err == nil:
+			result.Status = batchSuccessStatus(result.Op)
+			changed = append(changed, res.ID)
+		case errors.Is(res.Err, repo.ErrUserNotFound):
+			result.Status = http.StatusNotFound
+			result.Error = "User not found"
+		default:
+			logger.FromContext(c.Request().Context()).Errorf("Failed to apply batch operation %d: %v", result.Index, res.Err)
+			result.Status = http.StatusInternalServerError
+			result.Error = fmt.Sprintf("Failed to %s user", result.Op)
+		}
+	}
+	if len(changed) > 0 {
+		f.invalidateUsers(c.Request().Context(), changed...)
+	}
+	return c.JSON(http.StatusOK, results)
+}
+
+// decodeBatch читает операции из тела запроса потоково, не вычитывая тело целиком
+func decodeBatch(req *http.Request) ([]model.BatchOperation, error) {
+	decoder := json.NewDecoder(req.Body)
+	var ops []model.BatchOperation
+
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), mimeNDJSON) {
+		for {
+			var op model.BatchOperation
+			err := decoder.Decode(&op)
+			if err == io.EOF {
+				return ops, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(ops) == MaxBatchSize {
+				return nil, errBatchTooLarge
+			}
+			ops = append(ops, op)
+		}
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("batch must be a JSON array")
+	}
+	for decoder.More() {
+		var op model.BatchOperation
+		if err := decoder.Decode(&op); err != nil {
+			return nil, err
+		}
+		if len(ops) == MaxBatchSize {
+			return nil, errBatchTooLarge
+		}
+		ops = append(ops, op)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+func validateBatchOp(op model.BatchOperation) error {
+	switch op.Op {
+	case model.BatchCreate:
+		return validateUser(op.User, "user.")
+	case model.BatchUpdate:
+		if op.ID <= 0 {
+			return errors.New("id is required")
+		}
+		return validateUser(op.User, "user.")
+	case model.BatchDelete:
+		if op.ID <= 0 {
+			return errors.New("id is required")
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+	return nil
+}
+
+func batchSuccessStatus(op string) int {
+	switch op {
+	case model.BatchCreate:
+		return http.StatusCreated
+	case model.BatchDelete:
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
+
+func hasBatchDeletes(ops []model.BatchOperation) bool {
+	for _, op := range ops {
+		if op.Op == model.BatchDelete {
+			return true
+		}
+	}
+	return false
+}
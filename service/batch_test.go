@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-service/api_clients/model"
+	"user-service/repo"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestDecodeBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    int
+		wantErr     bool
+	}{
+		{
+			name:        "JSON array",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `[{"op": "create", "user": {"name": "Ivan", "surname": "Ivanov"}}, {"op": "delete", "id": 3}]`,
+			expected:    2,
+		},
+		{
+			name:        "NDJSON stream",
+			contentType: mimeNDJSON,
+			body:        "{\"op\": \"delete\", \"id\": 1}\n{\"op\": \"delete\", \"id\": 2}\n{\"op\": \"delete\", \"id\": 3}\n",
+			expected:    3,
+		},
+		{
+			name:        "Not an array",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"op": "delete", "id": 1}`,
+			wantErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(test.body))
+			req.Header.Set(echo.HeaderContentType, test.contentType)
+
+			ops, err := decodeBatch(req)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, but got %v", err)
+			}
+			if len(ops) != test.expected {
+				t.Errorf("got %d operations, wanted %d", len(ops), test.expected)
+			}
+		})
+	}
+}
+
+func TestBatchUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	body := `[
+		{"op": "create", "user": {"name": "Ivan", "surname": "Ivanov"}},
+		{"op": "create", "user": {"name": "Petr"}},
+		{"op": "delete", "id": 5}
+	]`
+
+	t.Run("Atomic batch with invalid operation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/?mode=atomic", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.BatchUsers)
+
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+
+	t.Run("Atomic batch rolled back", func(t *testing.T) {
+		mockUserRepo.EXPECT().ApplyBatch(gomock.Any(), gomock.Len(1), true).
+			Return(nil, &repo.BatchError{Index: 0, Err: repo.ErrUserNotFound})
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`[{"op": "delete", "id": 5}]`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.BatchUsers)
+
+		if got, want := rec.Code, http.StatusUnprocessableEntity; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+
+	t.Run("Partial batch reports every operation", func(t *testing.T) {
+		mockUserRepo.EXPECT().ApplyBatch(gomock.Any(), gomock.Len(2), false).Return([]repo.BatchOpResult{
+			{ID: 10},
+			{ID: 5, Err: errors.New("DB error")},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/?mode=partial", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.BatchUsers)
+
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("got status %d, wanted %d", got, want)
+		}
+
+		var results []model.BatchResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		statuses := []int{http.StatusCreated, http.StatusBadRequest, http.StatusInternalServerError}
+		for i, want := range statuses {
+			if got := results[i].Status; got != want {
+				t.Errorf("operation %d: got status %d, wanted %d", i, got, want)
+			}
+		}
+		if got, want := results[0].ID, 10; got != want {
+			t.Errorf("got id %d, wanted %d", got, want)
+		}
+	})
+}
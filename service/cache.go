@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"user-service/pkg/cache"
+	"user-service/pkg/logger"
+)
+
+// usersGenerationKey хранит поколение кэша списков пользователей. Поколение входит в ключ
+// каждой страницы списка, поэтому любое изменение, увеличивающее его, делает все
+// закэшированные страницы недостижимыми, и они дожидаются истечения срока жизни.
+const usersGenerationKey = "users:gen"
+
+// usersGeneration возвращает текущее поколение кэша списков. ok=false, если кэш недоступен
+// и читать/писать страницы в него не нужно.
+func (f *FIOService) usersGeneration(ctx context.Context) (gen int64, ok bool) {
+	if f.store == nil {
+		return 0, false
+	}
+
+	value, err := f.store.Get(ctx, usersGenerationKey)
+	if errors.Is(err, cache.ErrMiss) {
+		return 0, true
+	}
+	if err == nil {
+		gen, err = strconv.ParseInt(string(value), 10, 64)
+	}
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to read users cache generation:", err)
+		return 0, false
+	}
+	return gen, true
+}
+
+// usersListCacheKey - ключ страницы списка пользователей в заданном поколении
+func usersListCacheKey(gen int64, page, size int, filter string, includeDeleted bool) string {
+	return fmt.Sprintf("users:v=%d:p=%d:s=%d:f=%s:d=%t", gen, page, size, filter, includeDeleted)
+}
+
+// usersStatsCacheKey - ключ статистики пользователей в заданном поколении
+func usersStatsCacheKey(gen int64, bucket int, filter string, includeDeleted bool) string {
+	return fmt.Sprintf("users:stats:v=%d:b=%d:f=%s:d=%t", gen, bucket, filter, includeDeleted)
+}
+
+// userCacheKey - ключ одного пользователя
+func userCacheKey(id int) string {
+	return fmt.Sprintf("users:id=%d", id)
+}
+
+// invalidateUsers сбрасывает кэш после изменения пользователей: увеличивает поколение списков
+// и удаляет ключи перечисленных пользователей
+func (f *FIOService) invalidateUsers(ctx context.Context, ids ...int) {
+	if f.store == nil {
+		return
+	}
+
+	if _, err := f.store.Incr(ctx, usersGenerationKey); err != nil {
+		logger.FromContext(ctx).Error("Failed to bump users cache generation:", err)
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = userCacheKey(id)
+	}
+	if err := f.store.Del(ctx, keys...); err != nil {
+		logger.FromContext(ctx).Error("Failed to evict cached users:", err)
+	}
+}
+
+// loadCached читает значение через общий кэш, а если кэш не настроен или недоступен - напрямую из источника
+func (f *FIOService) loadCached(ctx context.Context, key string, cacheable bool, fetch cache.FetchFunc) ([]byte, error) {
+	if f.cache == nil || !cacheable {
+		return fetch(ctx)
+	}
+	return f.cache.Load(ctx, key, CacheExpiration, fetch)
+}
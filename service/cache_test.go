@@ -0,0 +1,76 @@
+package service
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-service/api_clients/model"
+	"user-service/pkg/cache"
+	"user-service/service/mocks"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func newTestRedis(t *testing.T) *cache.RedisCache {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return cache.NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+}
+
+func TestUsersCacheInvalidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	store := newTestRedis(t)
+	f := &FIOService{userRepo: mockUserRepo, store: store, cache: cache.NewReadThrough(store, store)}
+
+	call := func(handler echo.HandlerFunc, method, target, body string, params ...string) int {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if len(params) > 0 {
+			c.SetParamNames("id")
+			c.SetParamValues(params...)
+		}
+		handle(c, handler)
+		return rec.Code
+	}
+
+	t.Run("List pages are dropped after a write", func(t *testing.T) {
+		// первый запрос идет в базу, второй читается из кэша
+		mockUserRepo.EXPECT().GetUsers(gomock.Any(), 1, 10, gomock.Any()).Return([]model.User{{ID: 1}}, nil).Times(1)
+		call(f.GetUsers, http.MethodGet, "/users?page=1&size=10", "")
+		call(f.GetUsers, http.MethodGet, "/users?page=1&size=10", "")
+
+		mockUserRepo.EXPECT().AddUser(gomock.Any(), gomock.Any()).Return(2, nil)
+		call(f.AddUser, http.MethodPost, "/users", `{"name": "Franz", "surname": "Kafka"}`)
+
+		mockUserRepo.EXPECT().GetUsers(gomock.Any(), 1, 10, gomock.Any()).Return([]model.User{{ID: 1}, {ID: 2}}, nil).Times(1)
+		call(f.GetUsers, http.MethodGet, "/users?page=1&size=10", "")
+	})
+
+	t.Run("User key is evicted after delete", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), 5).Return(model.User{ID: 5, Name: "Ivan"}, nil).Times(1)
+		if got, want := call(f.GetUser, http.MethodGet, "/", "", "5"), http.StatusOK; got != want {
+			t.Fatalf("got status %d, wanted %d", got, want)
+		}
+		call(f.GetUser, http.MethodGet, "/", "", "5")
+
+		mockUserRepo.EXPECT().DeleteUser(gomock.Any(), 5).Return(nil)
+		call(f.DeleteUser, http.MethodDelete, "/", "", "5")
+
+		deletedAt := time.Now()
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), 5).Return(model.User{ID: 5, DeletedAt: &deletedAt}, nil).Times(1)
+		if got, want := call(f.GetUser, http.MethodGet, "/", "", "5"), http.StatusNotFound; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+}
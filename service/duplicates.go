@@ -0,0 +1,178 @@
+package service
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"user-service/api_clients/model"
+	"user-service/pkg/similarity"
+	"user-service/pkg/translit"
+	"user-service/repo"
+)
+
+// Значения по умолчанию для поиска дубликатов
+const (
+	DefaultDuplicateThreshold = 0.85
+	DefaultCandidateLimit     = 1000
+)
+
+// Веса частей ФИО в общем сходстве, отчество учитывается, только если оно есть у обоих
+const (
+	surnameWeight    = 0.5
+	nameWeight       = 0.4
+	patronymicWeight = 0.1
+)
+
+// Duplicates настраивает поиск дубликатов
+type Duplicates struct {
+	// Threshold - минимальное сходство ФИО, при котором пользователь считается кандидатом
+	Threshold float64
+	// CandidateLimit - сколько пользователей с похожей фамилией берется из базы для сравнения
+	CandidateLimit int
+}
+
+// GetDuplicates возвращает пользователей, похожих на пользователя id, по убыванию сходства.
+// Порог можно переопределить параметром threshold от 0 до 1.
+func (f *FIOService) GetDuplicates(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return InvalidParameter("id")
+	}
+
+	threshold := f.duplicates.Threshold
+	if threshold == 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+	if thresholdStr := c.QueryParam("threshold"); thresholdStr != "" {
+		threshold, err = strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return InvalidParameter("threshold")
+		}
+	}
+	limit := f.duplicates.CandidateLimit
+	if limit == 0 {
+		limit = DefaultCandidateLimit
+	}
+
+	ctx := c.Request().Context()
+	user, err := f.userRepo.GetUser(ctx, id)
+	if errors.Is(err, repo.ErrUserNotFound) || (err == nil && user.DeletedAt != nil) {
+		return NotFound("User not found")
+	}
+	if err != nil {
+		return Internal("Failed to fetch user", err)
+	}
+
+	users, err := f.userRepo.FindDuplicateCandidates(ctx, repo.DuplicateFilter{
+		ExcludeID:       id,
+		SurnamePrefixes: f.surnamePrefixes(user),
+		Limit:           limit,
+	})
+	if err != nil {
+		return Internal("Failed to find duplicates", err)
+	}
+
+	key := f.matchKey(user)
+	candidates := make([]model.DuplicateCandidate, 0)
+	for _, candidate := range users {
+		score := fioSimilarity(key, f.matchKey(candidate))
+		if score >= threshold {
+			candidates = append(candidates, model.DuplicateCandidate{User: candidate, Score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return c.JSON(http.StatusOK, candidates)
+}
+
+// MergeUsers сливает дубликаты из тела запроса в пользователя id: пустые поля заполняются из дубликатов,
+// их история переносится, сами дубликаты удаляются, в outbox пишется событие user.merged
+func (f *FIOService) MergeUsers(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return InvalidParameter("id")
+	}
+
+	var req model.MergeRequest
+	if err := c.Bind(&req); err != nil {
+		return InvalidBody(err)
+	}
+	if fields := validateMergeIDs(id, req.IDs); len(fields) > 0 {
+		return Validation("Invalid merge request", fields...)
+	}
+
+	user, err := f.userRepo.MergeUsers(requestContext(c), id, req.IDs)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return NotFound("User not found")
+	}
+	if err != nil {
+		return Internal("Failed to merge users", err)
+	}
+	f.invalidateUsers(c.Request().Context(), append([]int{id}, req.IDs...)...)
+	return c.JSON(http.StatusOK, user)
+}
+
+func validateMergeIDs(survivorID int, ids []int) []FieldError {
+	if len(ids) == 0 {
+		return []FieldError{{Field: "ids", Code: RuleRequired, Message: "is required"}}
+	}
+	var fields []FieldError
+	seen := make(map[int]bool, len(ids))
+	for i, id := range ids {
+		field := "ids[" + strconv.Itoa(i) + "]"
+		switch {
+		case id <= 0:
+			fields = append(fields, FieldError{Field: field, Code: RuleInvalidValue, Message: "must be a positive id"})
+		case id == survivorID:
+			fields = append(fields, FieldError{Field: field, Code: RuleInvalidValue, Message: "must differ from the surviving user"})
+		case seen[id]:
+			fields = append(fields, FieldError{Field: field, Code: RuleInvalidValue, Message: "is duplicated"})
+		}
+		seen[id] = true
+	}
+	return fields
+}
+
+// matchKey - ФИО для сравнения: нормализованное, латиницей по ICAO и в нижнем регистре,
+// чтобы "Пётр" и "Pyotr" сравнивались в одном алфавите
+func (f *FIOService) matchKey(user model.User) FIO {
+	fio := f.normalizeFIO(FIO{Name: user.Name, Surname: user.Surname, Patronymic: user.Patronymic})
+	return FIO{
+		Name:       strings.ToLower(translit.ICAO.String(fio.Name)),
+		Surname:    strings.ToLower(translit.ICAO.String(fio.Surname)),
+		Patronymic: strings.ToLower(translit.ICAO.String(fio.Patronymic)),
+	}
+}
+
+// surnamePrefixes - первая буква фамилии в исходном написании и латиницей для отбора кандидатов в базе
+func (f *FIOService) surnamePrefixes(user model.User) []string {
+	var prefixes []string
+	for _, surname := range []string{strings.ToLower(user.Surname), f.matchKey(user).Surname} {
+		r, _ := utf8.DecodeRuneInString(surname)
+		if r == utf8.RuneError {
+			continue
+		}
+		prefix := string(r)
+		if len(prefixes) == 0 || prefixes[0] != prefix {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// fioSimilarity - взвешенное сходство Джаро-Винклера фамилии, имени и отчества
+func fioSimilarity(a, b FIO) float64 {
+	score := surnameWeight*similarity.JaroWinkler(a.Surname, b.Surname) +
+		nameWeight*similarity.JaroWinkler(a.Name, b.Name)
+	total := surnameWeight + nameWeight
+	if a.Patronymic != "" && b.Patronymic != "" {
+		score += patronymicWeight * similarity.JaroWinkler(a.Patronymic, b.Patronymic)
+		total += patronymicWeight
+	}
+	return score / total
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"user-service/api_clients/model"
+	"user-service/pkg/cache"
+	"user-service/pkg/kafka"
+	"user-service/pkg/logger"
+	"user-service/repo"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestGetDuplicates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	petr := model.User{ID: 1, Name: "Petr", Surname: "Ushakov"}
+
+	t.Run("Candidates are scored and filtered", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), 1).Return(petr, nil)
+		mockUserRepo.EXPECT().FindDuplicateCandidates(gomock.Any(), repo.DuplicateFilter{
+			ExcludeID: 1, SurnamePrefixes: []string{"u"}, Limit: DefaultCandidateLimit,
+		}).Return([]model.User{
+			{ID: 2, Name: "Pyotr", Surname: "Ushakov", Patronymic: "Vasilevich"},
+			{ID: 3, Name: "Пётр", Surname: "Ушаков"},
+			{ID: 4, Name: "Uliana", Surname: "Utkina"},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/1/duplicates", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		handle(c, f.GetDuplicates)
+
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("got status %d, wanted %d", got, want)
+		}
+		var candidates []model.DuplicateCandidate
+		if err := json.Unmarshal(rec.Body.Bytes(), &candidates); err != nil {
+			t.Fatal(err)
+		}
+		if len(candidates) != 2 || candidates[0].User.ID != 3 || candidates[1].User.ID != 2 {
+			t.Fatalf("unexpected candidates %+v", candidates)
+		}
+		if candidates[0].Score != 1 || candidates[1].Score < DefaultDuplicateThreshold {
+			t.Errorf("unexpected scores %.3f and %.3f", candidates[0].Score, candidates[1].Score)
+		}
+	})
+
+	t.Run("Invalid threshold", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/1/duplicates?threshold=2", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		handle(c, f.GetDuplicates)
+
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), 9).Return(model.User{}, repo.ErrUserNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/9/duplicates", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("9")
+
+		handle(c, f.GetDuplicates)
+
+		if got, want := rec.Code, http.StatusNotFound; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+}
+
+func TestMergeUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	merge := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/1/merge", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		handle(c, f.MergeUsers)
+		return rec
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		repoErr  error
+		callRepo bool
+		expected int
+	}{
+		{name: "Ids are required", body: `{"ids": []}`, expected: http.StatusBadRequest},
+		{name: "Cannot merge into itself", body: `{"ids": [2, 1]}`, expected: http.StatusBadRequest},
+		{name: "Duplicate not found", body: `{"ids": [2]}`, repoErr: repo.ErrUserNotFound, callRepo: true, expected: http.StatusNotFound},
+		{name: "Failed to merge", body: `{"ids": [2]}`, repoErr: errors.New("DB error"), callRepo: true, expected: http.StatusInternalServerError},
+		{name: "Merged", body: `{"ids": [2]}`, callRepo: true, expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.callRepo {
+				mockUserRepo.EXPECT().MergeUsers(gomock.Any(), 1, []int{2}).Return(model.User{ID: 1}, test.repoErr)
+			}
+
+			rec := merge(test.body)
+
+			if got, want := rec.Code, test.expected; got != want {
+				t.Errorf("got status %d, wanted %d", got, want)
+			}
+		})
+	}
+}
+
+// Слитый дубликат удаляется мягко: он виден в списке с include_deleted и восстанавливается,
+// а кэш списка сбрасывается и после слияния, и после восстановления
+func TestMergedDuplicateIsRestorable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	store := cache.NewMemory(100, 1<<20)
+	f := &FIOService{userRepo: mockUserRepo, store: store, cache: cache.NewReadThrough(store, store)}
+	e := echo.New()
+
+	// хранилище с мягким удалением, как в repo/pgdb
+	users := map[int]*model.User{1: {ID: 1, Name: "Ivan"}, 2: {ID: 2, Name: "Ivan"}}
+	mockUserRepo.EXPECT().MergeUsers(gomock.Any(), 1, []int{2}).DoAndReturn(func(_ context.Context, survivorID int, ids []int) (model.User, error) {
+		now := time.Now()
+		for _, id := range ids {
+			users[id].DeletedAt = &now
+		}
+		return *users[survivorID], nil
+	})
+	mockUserRepo.EXPECT().RestoreUser(gomock.Any(), 2).DoAndReturn(func(_ context.Context, id int) error {
+		users[id].DeletedAt = nil
+		return nil
+	})
+	mockUserRepo.EXPECT().GetUsers(gomock.Any(), 1, 10, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _, _ int, filter repo.UserFilter) ([]model.User, error) {
+			var list []model.User
+			for id := 1; id <= len(users); id++ {
+				if users[id].DeletedAt == nil || filter.IncludeDeleted {
+					list = append(list, *users[id])
+				}
+			}
+			return list, nil
+		}).AnyTimes()
+
+	list := func() []model.User {
+		rec := httptest.NewRecorder()
+		handle(e.NewContext(httptest.NewRequest(http.MethodGet, "/users?page=1&size=10&include_deleted=true", nil), rec), f.GetUsers)
+		var got []model.User
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("unexpected body %s", rec.Body.String())
+		}
+		return got
+	}
+	list() // список попадает в кэш
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1/merge", bytes.NewBufferString(`{"ids": [2]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	handle(c, f.MergeUsers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("merge: got status %d", rec.Code)
+	}
+
+	got := list()
+	if len(got) != 2 || got[1].ID != 2 || got[1].DeletedAt == nil {
+		t.Fatalf("merged duplicate must be listed as deleted, got %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodPost, "/users/2/restore", nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+	handle(c, f.RestoreUser)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("restore: got status %d", rec.Code)
+	}
+
+	if got := list(); len(got) != 2 || got[1].DeletedAt != nil {
+		t.Fatalf("restored duplicate must not be deleted, got %+v", got)
+	}
+}
+
+func TestRelayOutbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOutboxRepo := mocks.NewMockOutboxRepo(ctrl)
+	f := &FIOService{outboxRepo: mockOutboxRepo}
+
+	relay := func(events ...model.OutboxEvent) func(context.Context, int, func([]model.OutboxEvent) error) (int, error) {
+		return func(_ context.Context, _ int, publish func([]model.OutboxEvent) error) (int, error) {
+			if err := publish(events); err != nil {
+				return 0, err
+			}
+			return len(events), nil
+		}
+	}
+	event := model.OutboxEvent{ID: 1, Type: model.EventUserMerged, Payload: json.RawMessage(`{}`), Headers: map[string]string{
+		"traceparent":          "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		logger.HeaderRequestID: "req-1",
+	}}
+
+	t.Run("Queue is drained while batches are full", func(t *testing.T) {
+		gomock.InOrder(
+			mockOutboxRepo.EXPECT().RelayOutbox(gomock.Any(), 2, gomock.Any()).DoAndReturn(relay(event, event)),
+			mockOutboxRepo.EXPECT().RelayOutbox(gomock.Any(), 2, gomock.Any()).DoAndReturn(relay(event)),
+		)
+
+		var published []kafka.Envelope
+		n, err := f.relayOutbox(context.Background(), 2, func(envelopes []kafka.Envelope) error {
+			published = append(published, envelopes...)
+			return nil
+		})
+		if err != nil || n != 3 || len(published) != 3 {
+			t.Fatalf("got %d relayed, %d published, err %v, wanted 3", n, len(published), err)
+		}
+		// заголовки запроса, в котором записано событие, уходят в сообщение, а не в его тело
+		if got := published[0].Headers[logger.HeaderRequestID]; got != "req-1" {
+			t.Errorf("got request id header %q, wanted req-1", got)
+		}
+		if got := published[0].Headers["traceparent"]; got != event.Headers["traceparent"] {
+			t.Errorf("got traceparent header %q, wanted %q", got, event.Headers["traceparent"])
+		}
+		if bytes.Contains(published[0].Value, []byte("req-1")) {
+			t.Errorf("headers must not be in the message body: %s", published[0].Value)
+		}
+	})
+
+	t.Run("Publish error stops the relay", func(t *testing.T) {
+		mockOutboxRepo.EXPECT().RelayOutbox(gomock.Any(), 2, gomock.Any()).DoAndReturn(relay(event))
+
+		_, err := f.relayOutbox(context.Background(), 2, func([]kafka.Envelope) error {
+			return errors.New("kafka is down")
+		})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestRunOutboxRelayWithoutInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	f := &FIOService{outboxRepo: mocks.NewMockOutboxRepo(ctrl), stopCh: make(chan bool)}
+	close(f.stopCh)
+
+	// нулевой и отрицательный интервал заменяются значением по умолчанию вместо паники в time.NewTicker
+	f.RunOutboxRelay("user-events", 0, 0)
+	f.RunOutboxRelay("user-events", -time.Second, 0)
+}
@@ -1,6 +1,9 @@
 package service
 
-import "user-service/api_clients/client"
+import (
+	"context"
+	"user-service/api_clients/client"
+)
 
 type EnrichedFIO struct {
 	Name        string
@@ -11,29 +14,33 @@ type EnrichedFIO struct {
 	Nationality []client.CountryProbability
 }
 
-func (f *FIOService) enrichFIOData(fioMessage FIO) (EnrichedFIO, error) {
+// enrichFIOData определяет возраст, пол и национальность по имени. Сервисы обогащения лучше знают
+// латинские имена, поэтому запрос идет по транслитерации, а в результате остается исходное написание.
+func (f *FIOService) enrichFIOData(ctx context.Context, fioMessage FIO) (EnrichedFIO, error) {
 	enriched := EnrichedFIO{
 		Name:       fioMessage.Name,
 		Surname:    fioMessage.Surname,
 		Patronymic: fioMessage.Patronymic,
 	}
 
+	lookup := f.latin.Scheme.String(fioMessage.Name)
+
 	// Обогащение возраста
-	ageData, err := client.GetAgeByName(fioMessage.Name)
+	ageData, err := client.GetAgeByName(ctx, lookup)
 	if err != nil {
 		return EnrichedFIO{}, err
 	}
 	enriched.Age = ageData.Age
 
 	// Обогащение пола
-	genderData, err := client.GetGenderByName(fioMessage.Name)
+	genderData, err := client.GetGenderByName(ctx, lookup)
 	if err != nil {
 		return EnrichedFIO{}, err
 	}
 	enriched.Gender = genderData.Gender
 
 	// Обогащение национальности
-	nationalityData, err := client.GetNationalityByName(fioMessage.Name)
+	nationalityData, err := client.GetNationalityByName(ctx, lookup)
 	if err != nil {
 		return EnrichedFIO{}, err
 	}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"user-service/pkg/logger"
+	"user-service/repo"
+)
+
+// MIMEProblemJSON - тип ответа с ошибкой по RFC 7807
+const MIMEProblemJSON = "application/problem+json"
+
+// Стабильные коды ошибок API, клиенты могут на них опираться
+const (
+	CodeInvalidParameter = "invalid_parameter"
+	CodeInvalidBody      = "invalid_body"
+	CodeValidation       = "validation_failed"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeUnprocessable    = "unprocessable"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal"
+)
+
+// FieldError - ошибка в конкретном поле запроса, Field - путь к полю, Code - код нарушенного правила
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error - ошибка API с HTTP-статусом и стабильным кодом. Обработчики возвращают ее,
+// а в ответ application/problem+json ее превращает HTTPErrorHandler.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Fields []FieldError
+	// Extensions - дополнительные поля ответа, например результаты операций пакета
+	Extensions map[string]interface{}
+	// Err - причина, в ответ не попадает, только в лог
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %s", e.Code, e.Detail, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With добавляет поле в ответ
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]interface{})
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+// InvalidParameter - некорректный параметр пути или запроса
+func InvalidParameter(name string) *Error {
+	return &Error{
+		Status: http.StatusBadRequest,
+		Code:   CodeInvalidParameter,
+		Detail: fmt.Sprintf("Invalid %s parameter", name),
+		Fields: []FieldError{{Field: name, Code: RuleInvalidValue, Message: "invalid value"}},
+	}
+}
+
+// InvalidBody - тело запроса не разобрано
+func InvalidBody(err error) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidBody, Detail: "Failed to parse request body", Err: err}
+}
+
+// Validation - запрос разобран, но данные некорректны
+func Validation(detail string, fields ...FieldError) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeValidation, Detail: detail, Fields: fields}
+}
+
+func NotFound(detail string) *Error {
+	return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Detail: detail}
+}
+
+func Conflict(detail string, err error) *Error {
+	return &Error{Status: http.StatusConflict, Code: CodeConflict, Detail: detail, Err: err}
+}
+
+func Unauthorized(detail string) *Error {
+	return &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Detail: detail}
+}
+
+func TooManyRequests(detail string) *Error {
+	return &Error{Status: http.StatusTooManyRequests, Code: CodeRateLimited, Detail: detail}
+}
+
+// Internal - ошибка сервиса, detail описывает операцию, причина пишется в лог
+func Internal(detail string, err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: detail, Err: err}
+}
+
+// ErrorStatus - HTTP-статус, с которым HTTPErrorHandler отдаст ошибку
+func ErrorStatus(err error) int {
+	return toError(err).Status
+}
+
+// HTTPErrorHandler отвечает на ошибку обработчика в формате application/problem+json.
+// Ошибки хранилища без обертки превращаются в 404 и 409, остальные - в 500 с записью в лог.
+func HTTPErrorHandler(err error, c echo.Context) {
+	entry := logger.FromContext(c.Request().Context())
+	if c.Response().Committed {
+		entry.Error("Error after response was sent:", err)
+		return
+	}
+
+	apiErr := toError(err)
+	requestID := logger.RequestID(c.Request().Context())
+	if requestID == "" {
+		requestID = c.Response().Header().Get(echo.HeaderXRequestID)
+	}
+	if requestID == "" {
+		requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+	}
+	if apiErr.Status >= http.StatusInternalServerError {
+		entry.WithFields(log.Fields{
+			"method": c.Request().Method,
+			"path":   c.Request().URL.Path,
+		}).Error(apiErr.Detail+": ", apiErr.Err)
+	}
+
+	problem := map[string]interface{}{}
+	for key, value := range apiErr.Extensions {
+		problem[key] = value
+	}
+	problem["type"] = "/problems/" + apiErr.Code
+	problem["title"] = http.StatusText(apiErr.Status)
+	problem["status"] = apiErr.Status
+	problem["detail"] = apiErr.Detail
+	problem["instance"] = c.Request().URL.Path
+	problem["code"] = apiErr.Code
+	if requestID != "" {
+		problem["request_id"] = requestID
+	}
+	if len(apiErr.Fields) > 0 {
+		problem["errors"] = apiErr.Fields
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.Status)
+	} else {
+		err = writeProblem(c, apiErr.Status, problem)
+	}
+	if err != nil {
+		entry.Error("Failed to write error response:", err)
+	}
+}
+
+func writeProblem(c echo.Context, status int, problem map[string]interface{}) error {
+	data, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Blob(status, MIMEProblemJSON, data)
+}
+
+// toError приводит любую ошибку к ошибке API
+func toError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return &Error{
+			Status: httpErr.Code,
+			Code:   codeForStatus(httpErr.Code),
+			Detail: fmt.Sprint(httpErr.Message),
+			Err:    httpErr.Internal,
+		}
+	}
+
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		return NotFound(err.Error())
+	case errors.Is(err, repo.ErrConflict):
+		return Conflict("Conflicting state", err)
+	}
+	return Internal("Internal server error", err)
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidBody
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeUnprocessable
+}
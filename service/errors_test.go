@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-service/repo"
+
+	"github.com/labstack/echo/v4"
+)
+
+// handle вызывает обработчик и отдает его ошибку в HTTPErrorHandler, как это делает echo
+func handle(c echo.Context, h echo.HandlerFunc) {
+	if err := h(c); err != nil {
+		HTTPErrorHandler(err, c)
+	}
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	e := echo.New()
+
+	serve := func(err error) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+		rec := httptest.NewRecorder()
+		HTTPErrorHandler(err, e.NewContext(req, rec))
+
+		var problem map[string]interface{}
+		if rec.Body.Len() > 0 {
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid body %s: %v", rec.Body.String(), err)
+			}
+		}
+		return rec, problem
+	}
+
+	t.Run("API error is rendered as problem+json", func(t *testing.T) {
+		rec, problem := serve(Validation("Both name and surname are required", FieldError{Field: "name", Code: RuleRequired, Message: "is required"}))
+
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		if got, want := rec.Header().Get(echo.HeaderContentType), MIMEProblemJSON; got != want {
+			t.Errorf("got content type %q, wanted %q", got, want)
+		}
+		want := map[string]interface{}{
+			"type":       "/problems/validation_failed",
+			"title":      "Bad Request",
+			"detail":     "Both name and surname are required",
+			"instance":   "/users/7",
+			"code":       CodeValidation,
+			"request_id": "req-1",
+		}
+		for key, value := range want {
+			if problem[key] != value {
+				t.Errorf("got %s %v, wanted %v", key, problem[key], value)
+			}
+		}
+		if fields, _ := problem["errors"].([]interface{}); len(fields) != 1 {
+			t.Errorf("got errors %v, wanted one field error", problem["errors"])
+		}
+	})
+
+	t.Run("Repository errors are mapped", func(t *testing.T) {
+		rec, problem := serve(repo.ErrUserNotFound)
+		if got, want := rec.Code, http.StatusNotFound; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		if problem["code"] != CodeNotFound {
+			t.Errorf("got code %v, wanted %s", problem["code"], CodeNotFound)
+		}
+
+		rec, _ = serve(repo.ErrConflict)
+		if got, want := rec.Code, http.StatusConflict; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+
+	t.Run("Unknown error hides the cause", func(t *testing.T) {
+		rec, problem := serve(errors.New("connection refused"))
+		if got, want := rec.Code, http.StatusInternalServerError; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		if problem["detail"] != "Internal server error" {
+			t.Errorf("got detail %v, cause must not leak", problem["detail"])
+		}
+	})
+
+	t.Run("Echo errors keep their status", func(t *testing.T) {
+		rec, problem := serve(echo.ErrMethodNotAllowed)
+		if got, want := rec.Code, http.StatusMethodNotAllowed; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		if problem["code"] != CodeMethodNotAllowed {
+			t.Errorf("got code %v, wanted %s", problem["code"], CodeMethodNotAllowed)
+		}
+	})
+}
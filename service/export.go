@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"user-service/api_clients/model"
+	"user-service/pkg/logger"
+)
+
+// Форматы выгрузки пользователей
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportJSON   = "json"
+)
+
+// exportFlushEvery - через сколько строк выгрузка сбрасывается клиенту
+const exportFlushEvery = 1000
+
+// exportColumns - поля, доступные для выгрузки, в порядке по умолчанию
+var exportColumns = []string{"id", "name", "surname", "patronymic", "age", "gender", "nationality", "deleted_at"}
+
+// userExporter пишет пользователей в выбранном формате
+type userExporter interface {
+	Begin() error
+	Write(user model.User) error
+	// Flush сбрасывает накопленные данные в нижележащий writer
+	Flush() error
+	// End завершает документ и сбрасывает остаток данных
+	End() error
+}
+
+// ExportUsers выгружает пользователей в csv, ndjson или json с теми же фильтрами, что и GetUsers.
+// Строки читаются из базы курсором и сразу пишутся в ответ, ответ сжимается gzip, если клиент это поддерживает.
+func (f *FIOService) ExportUsers(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = ExportCSV
+	}
+
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		return err
+	}
+
+	columns, err := parseExportColumns(c.QueryParam("fields"))
+	if err != nil {
+		return err
+	}
+
+	delimiter, err := parseDelimiter(c.QueryParam("delimiter"))
+	if err != nil {
+		return err
+	}
+
+	resp := c.Response()
+	var out io.Writer = resp
+	var gz *gzip.Writer
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAcceptEncoding), "gzip") {
+		gz = gzip.NewWriter(resp)
+		out = gz
+	}
+
+	var exporter userExporter
+	var contentType string
+	switch format {
+	case ExportCSV:
+		exporter = newCSVExporter(out, columns, delimiter)
+		contentType = "text/csv; charset=UTF-8"
+	case ExportNDJSON:
+		exporter = newJSONExporter(out, columns, false)
+		contentType = mimeNDJSON
+	case ExportJSON:
+		exporter = newJSONExporter(out, columns, true)
+		contentType = echo.MIMEApplicationJSONCharsetUTF8
+	default:
+		return InvalidParameter("format")
+	}
+
+	resp.Header().Set(echo.HeaderContentType, contentType)
+	resp.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=users.%s", format))
+	resp.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	if gz != nil {
+		resp.Header().Set(echo.HeaderContentEncoding, "gzip")
+	}
+
+	flush := func() error {
+		if err := exporter.Flush(); err != nil {
+			return err
+		}
+		if gz != nil {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+		resp.Flush()
+		return nil
+	}
+
+	written := 0
+	err = exporter.Begin()
+	if err == nil {
+		err = f.userRepo.StreamUsers(c.Request().Context(), filter, func(user model.User) error {
+			if err := exporter.Write(user); err != nil {
+				return err
+			}
+			written++
+			if written%exportFlushEvery == 0 {
+				return flush()
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = exporter.End()
+	}
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+
+	if err != nil {
+		// Пока клиенту ничего не отправлено, еще можно ответить ошибкой
+		if !resp.Committed {
+			resp.Header().Del(echo.HeaderContentEncoding)
+			resp.Header().Del(echo.HeaderContentDisposition)
+			return Internal("Failed to export users", err)
+		}
+		logger.FromContext(c.Request().Context()).Errorf("Failed to export users after %d rows: %v", written, err)
+	}
+	return nil
+}
+
+func parseExportColumns(fields string) ([]string, error) {
+	if fields == "" {
+		return exportColumns, nil
+	}
+
+	var columns []string
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if !isExportColumn(field) {
+			err := InvalidParameter("fields")
+			err.Fields[0].Message = fmt.Sprintf("unknown field %q", field)
+			return nil, err
+		}
+		columns = append(columns, field)
+	}
+	return columns, nil
+}
+
+func isExportColumn(field string) bool {
+	for _, column := range exportColumns {
+		if column == field {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDelimiter возвращает разделитель полей csv: по умолчанию запятая, "tab" или "\t" - табуляция
+func parseDelimiter(delimiter string) (rune, error) {
+	switch delimiter {
+	case "":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, InvalidParameter("delimiter")
+	}
+	return r, nil
+}
+
+func exportValue(user model.User, column string) interface{} {
+	switch column {
+	case "id":
+		return user.ID
+	case "name":
+		return user.Name
+	case "surname":
+		return user.Surname
+	case "patronymic":
+		return user.Patronymic
+	case "age":
+		return user.Age
+	case "gender":
+		return user.Gender
+	case "nationality":
+		return user.Nationality
+	case "deleted_at":
+		return user.DeletedAt
+	}
+	return nil
+}
+
+type csvExporter struct {
+	writer  *csv.Writer
+	columns []string
+	record  []string
+}
+
+func newCSVExporter(w io.Writer, columns []string, delimiter rune) *csvExporter {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	return &csvExporter{
+		writer:  writer,
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+}
+
+func (e *csvExporter) Begin() error {
+	return e.writer.Write(e.columns)
+}
+
+func (e *csvExporter) Write(user model.User) error {
+	for i, column := range e.columns {
+		switch value := exportValue(user, column).(type) {
+		case int:
+			e.record[i] = strconv.Itoa(value)
+		case string:
+			e.record[i] = value
+		case *time.Time:
+			e.record[i] = ""
+			if value != nil {
+				e.record[i] = value.Format(time.RFC3339)
+			}
+		}
+	}
+	return e.writer.Write(e.record)
+}
+
+func (e *csvExporter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) End() error {
+	return e.Flush()
+}
+
+// jsonExporter пишет объекты построчно (ndjson) или элементами одного массива (json)
+type jsonExporter struct {
+	writer  *bufio.Writer
+	columns []string
+	array   bool
+	count   int
+}
+
+func newJSONExporter(w io.Writer, columns []string, array bool) *jsonExporter {
+	return &jsonExporter{
+		writer:  bufio.NewWriter(w),
+		columns: columns,
+		array:   array,
+	}
+}
+
+func (e *jsonExporter) Begin() error {
+	if e.array {
+		return e.writer.WriteByte('[')
+	}
+	return nil
+}
+
+func (e *jsonExporter) Write(user model.User) error {
+	if e.array && e.count > 0 {
+		e.writer.WriteByte(',')
+	}
+	e.count++
+
+	// Поля пишутся вручную, чтобы сохранить порядок, заданный в fields
+	e.writer.WriteByte('{')
+	for i, column := range e.columns {
+		if i > 0 {
+			e.writer.WriteByte(',')
+		}
+		value, err := json.Marshal(exportValue(user, column))
+		if err != nil {
+			return err
+		}
+		e.writer.WriteString(strconv.Quote(column))
+		e.writer.WriteByte(':')
+		e.writer.Write(value)
+	}
+	e.writer.WriteByte('}')
+
+	if !e.array {
+		return e.writer.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *jsonExporter) Flush() error {
+	return e.writer.Flush()
+}
+
+func (e *jsonExporter) End() error {
+	if e.array {
+		e.writer.WriteByte(']')
+	}
+	return e.Flush()
+}
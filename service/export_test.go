@@ -0,0 +1,134 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-service/api_clients/model"
+	"user-service/repo"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestExportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	users := []model.User{
+		{ID: 1, Name: "Ivan", Surname: "Ivanov", Age: 30},
+		{ID: 2, Name: "Anna", Surname: "Smith, Jr."},
+	}
+	streamUsers := func(ctx context.Context, filter repo.UserFilter, fn func(model.User) error) error {
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "CSV with custom delimiter",
+			query:    "?format=csv&fields=id,surname,age&delimiter=%3B",
+			expected: "id;surname;age\n1;Ivanov;30\n2;Smith, Jr.;0\n",
+		},
+		{
+			name:     "CSV quotes delimiter inside values",
+			query:    "?fields=name,surname",
+			expected: "name,surname\nIvan,Ivanov\nAnna,\"Smith, Jr.\"\n",
+		},
+		{
+			name:     "NDJSON",
+			query:    "?format=ndjson&fields=id,name",
+			expected: "{\"id\":1,\"name\":\"Ivan\"}\n{\"id\":2,\"name\":\"Anna\"}\n",
+		},
+		{
+			name:     "JSON array",
+			query:    "?format=json&fields=id",
+			expected: `[{"id":1},{"id":2}]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockUserRepo.EXPECT().StreamUsers(gomock.Any(), repo.UserFilter{}, gomock.Any()).DoAndReturn(streamUsers)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/export"+test.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handle(c, f.ExportUsers)
+
+			if got, want := rec.Code, http.StatusOK; got != want {
+				t.Fatalf("got status %d, wanted %d", got, want)
+			}
+			if got := rec.Body.String(); got != test.expected {
+				t.Errorf("got body %q, wanted %q", got, test.expected)
+			}
+		})
+	}
+
+	t.Run("Gzip when accepted", func(t *testing.T) {
+		mockUserRepo.EXPECT().StreamUsers(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(streamUsers)
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export?format=json&fields=id", nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, "gzip, deflate")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.ExportUsers)
+
+		if got, want := rec.Header().Get(echo.HeaderContentEncoding), "gzip"; got != want {
+			t.Fatalf("got Content-Encoding %q, wanted %q", got, want)
+		}
+		gz, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("failed to open gzip body: %v", err)
+		}
+		body, _ := io.ReadAll(gz)
+		if got, want := string(body), `[{"id":1},{"id":2}]`; got != want {
+			t.Errorf("got body %q, wanted %q", got, want)
+		}
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/export?fields=id,password", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.ExportUsers)
+
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+
+	t.Run("Failed before anything was sent", func(t *testing.T) {
+		mockUserRepo.EXPECT().StreamUsers(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("DB error"))
+
+		req := httptest.NewRequest(http.MethodGet, "/users/export", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.ExportUsers)
+
+		if got, want := rec.Code, http.StatusInternalServerError; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"user-service/api_clients/model"
+	"user-service/pkg/logger"
+	"user-service/repo"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Форматы файлов импорта
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+// Режимы импорта: enrich - провалидировать, обогатить и сохранить каждую строку,
+// publish - опубликовать валидные строки в топик ФИО для обычной обработки консьюмером
+const (
+	ImportModeEnrich  = "enrich"
+	ImportModePublish = "publish"
+)
+
+const (
+	// importProgressEvery - через сколько строк сохраняется прогресс задачи
+	importProgressEvery = 500
+	// importBatchSize - сколько ошибок или сообщений накапливается перед записью
+	importBatchSize = 100
+	// maxImportErrors - сколько ошибок по строкам сохраняется для одной задачи, остальные только считаются
+	maxImportErrors = 10000
+	// maxImportLine - максимальная длина строки NDJSON
+	maxImportLine = 1 << 20
+
+	// importSaveTimeout - сколько ждать сохранения состояния прерванного импорта
+	importSaveTimeout = 10 * time.Second
+
+	defaultImportErrorsSize = 100
+)
+
+// ErrInvalidImport возвращается, если у задачи импорта неизвестный формат или режим
+var ErrInvalidImport = errors.New("invalid import")
+
+// errServiceStopping - импорт не запущен, потому что сервис уже останавливается
+var errServiceStopping = errors.New("service is stopping")
+
+// CreateImport принимает файл ФИО (multipart, поле file) и запускает его импорт в фоне.
+// Формат берется из поля format или из расширения файла, режим - из поля mode (по умолчанию enrich).
+func (f *FIOService) CreateImport(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return Validation("File is required", FieldError{Field: "file", Code: RuleRequired, Message: "is required"})
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return &Error{Status: http.StatusBadRequest, Code: CodeInvalidBody, Detail: "Failed to read file", Err: err}
+	}
+	defer src.Close()
+
+	imp, err := f.NewImport(requestContext(c), fileHeader.Filename, c.FormValue("format"), c.FormValue("mode"))
+	if errors.Is(err, ErrInvalidImport) {
+		return Validation(err.Error())
+	}
+	if err != nil {
+		return Internal("Failed to create import", err)
+	}
+
+	// Файл из multipart удаляется после ответа, поэтому обрабатываем его копию
+	tmp, err := os.CreateTemp("", "import-*")
+	if err == nil {
+		_, err = io.Copy(tmp, src)
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+		return Internal("Failed to store import file", err)
+	}
+
+	// импорт переживает запрос, но продолжает его трассу и идентификатор в логах и опубликованных сообщениях
+	started := f.goImport(logger.Detach(c.Request().Context()), func(ctx context.Context) {
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		f.RunImport(ctx, imp, tmp)
+	})
+	if !started {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		finishedAt := time.Now()
+		imp.Status, imp.Error, imp.FinishedAt = model.ImportFailed, errServiceStopping.Error(), &finishedAt
+		f.saveImport(c.Request().Context(), imp)
+		return &Error{Status: http.StatusServiceUnavailable, Detail: "Service is stopping", Err: errServiceStopping}
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/imports/%d", imp.ID))
+	return c.JSON(http.StatusAccepted, imp)
+}
+
+// GetImport возвращает статус задачи импорта и страницу ошибок по строкам
+func (f *FIOService) GetImport(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return InvalidParameter("id")
+	}
+
+	page, size := 1, defaultImportErrorsSize
+	if pageStr := c.QueryParam("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return InvalidParameter("page")
+		}
+	}
+	if sizeStr := c.QueryParam("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size < 1 {
+			return InvalidParameter("size")
+		}
+	}
+
+	imp, err := f.importRepo.GetImport(c.Request().Context(), id, page, size)
+	if errors.Is(err, repo.ErrImportNotFound) {
+		return NotFound("Import not found")
+	}
+	if err != nil {
+		return Internal("Failed to fetch import", err)
+	}
+	return c.JSON(http.StatusOK, imp)
+}
+
+// NewImport проверяет формат и режим и создает задачу импорта в статусе pending
+func (f *FIOService) NewImport(ctx context.Context, filename, format, mode string) (model.Import, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			format = ImportCSV
+		case ".ndjson", ".jsonl":
+			format = ImportNDJSON
+		}
+	}
+	if format != ImportCSV && format != ImportNDJSON {
+		return model.Import{}, fmt.Errorf("%w: unknown format %q", ErrInvalidImport, format)
+	}
+
+	if mode == "" {
+		mode = ImportModeEnrich
+	}
+	if mode != ImportModeEnrich && mode != ImportModePublish {
+		return model.Import{}, fmt.Errorf("%w: unknown mode %q", ErrInvalidImport, mode)
+	}
+
+	imp := model.Import{
+		Filename:  filepath.Base(filename),
+		Format:    format,
+		Mode:      mode,
+		Status:    model.ImportPending,
+		CreatedAt: time.Now(),
+	}
+	id, err := f.importRepo.CreateImport(ctx, imp)
+	if err != nil {
+		return model.Import{}, err
+	}
+	imp.ID = id
+	return imp, nil
+}
+
+// RunImport построчно обрабатывает файл задачи: каждая строка проходит IsValid и затем
+// обогащение (enrich) или публикацию в топик ФИО (publish). Прогресс и ошибки по строкам
+// сохраняются по ходу работы, возвращается итоговое состояние задачи.
+func (f *FIOService) RunImport(ctx context.Context, imp model.Import, r io.Reader) model.Import {
+	// импорт переживает спан запроса, поэтому продолжает трассу своим спаном
+	ctx, span := otel.Tracer(tracerScope).Start(ctx, "import", trace.WithAttributes(attribute.Int64("import.id", imp.ID)))
+	defer span.End()
+	ctx = repo.WithActor(ctx, repo.Actor{Name: fmt.Sprintf("import/%d", imp.ID), Source: repo.SourceImport})
+
+	imp.Status = model.ImportRunning
+	f.saveImport(ctx, imp)
+
+	var rowErrors []model.ImportRowError
+	storedErrors := 0
+	fail := func(row int, raw string, err error) {
+		imp.FailedRows++
+		if storedErrors == maxImportErrors {
+			return
+		}
+		storedErrors++
+		rowErrors = append(rowErrors, model.ImportRowError{Row: row, Error: err.Error(), Raw: raw})
+		if len(rowErrors) == importBatchSize {
+			f.saveImportErrors(ctx, imp.ID, rowErrors)
+			rowErrors = rowErrors[:0]
+		}
+	}
+
+	// В режиме publish сообщения копятся и отправляются пачкой, ошибка отправки достается всем строкам пачки
+	type pendingRow struct {
+		row  int
+		raw  string
+		data []byte
+	}
+	var pending []pendingRow
+	publish := func() {
+		if len(pending) == 0 {
+			return
+		}
+		values := make([][]byte, len(pending))
+		for i, p := range pending {
+			values[i] = p.data
+		}
+		if err := f.kafkaService.PublishBatch(ctx, f.kafkaService.Topic, values); err != nil {
+			for _, p := range pending {
+				fail(p.row, p.raw, fmt.Errorf("failed to publish: %w", err))
+			}
+		}
+		pending = pending[:0]
+	}
+
+	err := parseImport(r, imp.Format, func(row int, fio FIO, raw string, rowErr error) error {
+		imp.ProcessedRows++
+		normalized := f.normalizeFIO(fio)
+		if rowErr == nil {
+			rowErr = normalized.IsValid()
+		}
+
+		switch {
+		case rowErr != nil:
+			fail(row, raw, rowErr)
+		case imp.Mode == ImportModePublish:
+			data, err := json.Marshal(fio)
+			if err != nil {
+				fail(row, raw, err)
+				break
+			}
+			pending = append(pending, pendingRow{row: row, raw: raw, data: data})
+			if len(pending) == importBatchSize {
+				publish()
+			}
+		default:
+			if err := f.enrichAndSave(ctx, normalized, fio); err != nil {
+				fail(row, raw, err)
+			}
+		}
+
+		if imp.ProcessedRows%importProgressEvery == 0 {
+			f.saveImport(ctx, imp)
+		}
+		return ctx.Err()
+	})
+	if ctx.Err() != nil {
+		// импорт прерван остановкой: контекст уже отменен, а накопленное и итоговый статус
+		// все равно нужно сохранить, иначе задача навсегда останется в статусе running
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(logger.Detach(ctx), importSaveTimeout)
+		defer cancel()
+		err = fmt.Errorf("import interrupted: %w", err)
+	}
+	publish()
+	if len(rowErrors) > 0 {
+		f.saveImportErrors(ctx, imp.ID, rowErrors)
+	}
+
+	finishedAt := time.Now()
+	imp.FinishedAt = &finishedAt
+	imp.Status = model.ImportCompleted
+	if err != nil {
+		imp.Status = model.ImportFailed
+		imp.Error = err.Error()
+	}
+	f.saveImport(ctx, imp)
+	return imp
+}
+
+// goImport запускает импорт в фоне. Контекст импорта отменяется при Stop, а WaitImports дожидается
+// его завершения. После Stop новые импорты не запускаются, тогда возвращается false.
+func (f *FIOService) goImport(ctx context.Context, run func(ctx context.Context)) bool {
+	f.importsMu.Lock()
+	defer f.importsMu.Unlock()
+	select {
+	case <-f.stopCh:
+		return false
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	f.imports.Add(1)
+	go func() {
+		select {
+		case <-f.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		defer f.imports.Done()
+		defer cancel()
+		run(ctx)
+	}()
+	return true
+}
+
+// WaitImports ждет завершения фоновых импортов, но не дольше timeout. Вызывается после Stop,
+// поэтому импорты уже прерваны и только сохраняют свое состояние. Возвращает false, если не дождался.
+func (f *FIOService) WaitImports(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		f.imports.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (f *FIOService) saveImport(ctx context.Context, imp model.Import) {
+	if err := f.importRepo.UpdateImport(ctx, imp); err != nil {
+		logger.FromContext(ctx).Errorf("Failed to update import %d: %v", imp.ID, err)
+	}
+}
+
+func (f *FIOService) saveImportErrors(ctx context.Context, importID int64, rowErrors []model.ImportRowError) {
+	if err := f.importRepo.AddImportErrors(ctx, importID, rowErrors); err != nil {
+		logger.FromContext(ctx).Errorf("Failed to save errors of import %d: %v", importID, err)
+	}
+}
+
+// parseImport читает файл и вызывает fn для каждой строки данных. Ошибка разбора отдельной строки
+// передается в fn, ошибка всего файла (нечитаемый заголовок, обрыв чтения) возвращается сразу.
+func parseImport(r io.Reader, format string, fn func(row int, fio FIO, raw string, err error) error) error {
+	if format == ImportNDJSON {
+		return parseNDJSON(r, fn)
+	}
+	return parseCSV(r, fn)
+}
+
+func parseNDJSON(r io.Reader, fn func(row int, fio FIO, raw string, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		row++
+
+		var fio FIO
+		err := json.Unmarshal([]byte(line), &fio)
+		if err := fn(row, fio, line, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// parseCSV ожидает заголовок с колонками name, surname и необязательной patronymic.
+// Разделитель (запятая, точка с запятой или табуляция) определяется по заголовку.
+func parseCSV(r io.Reader, fn func(row int, fio FIO, raw string, err error) error) error {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	delimiter := detectDelimiter(header)
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), br))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	columns, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	index := map[string]int{}
+	for i, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		index[column] = i
+	}
+	for _, required := range []string{"name", "surname"} {
+		if _, ok := index[required]; !ok {
+			return fmt.Errorf("header has no %q column", required)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := index[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		row++
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := fn(row, FIO{}, strings.Join(record, string(delimiter)), err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		fio := FIO{
+			Name:       field(record, "name"),
+			Surname:    field(record, "surname"),
+			Patronymic: field(record, "patronymic"),
+		}
+		if err := fn(row, fio, strings.Join(record, string(delimiter)), nil); err != nil {
+			return err
+		}
+	}
+}
+
+func detectDelimiter(header string) rune {
+	delimiter, best := ',', strings.Count(header, ",")
+	for _, candidate := range []rune{';', '\t'} {
+		if n := strings.Count(header, string(candidate)); n > best {
+			delimiter, best = candidate, n
+		}
+	}
+	return delimiter
+}
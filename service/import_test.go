@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"user-service/api_clients/model"
+	"user-service/repo"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+type parsedRow struct {
+	row    int
+	fio    FIO
+	hasErr bool
+}
+
+func collectRows(t *testing.T, format, data string) []parsedRow {
+	t.Helper()
+	var rows []parsedRow
+	err := parseImport(strings.NewReader(data), format, func(row int, fio FIO, raw string, err error) error {
+		rows = append(rows, parsedRow{row: row, fio: fio, hasErr: err != nil})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, but got %v", err)
+	}
+	return rows
+}
+
+func TestParseImport(t *testing.T) {
+	t.Run("CSV with semicolon and BOM", func(t *testing.T) {
+		data := "\ufeffSurname;Name;Patronymic\nUshakov;Petr;Vasilevich\nIvanov;Ivan;\n"
+		rows := collectRows(t, ImportCSV, data)
+
+		expected := []parsedRow{
+			{row: 1, fio: FIO{Name: "Petr", Surname: "Ushakov", Patronymic: "Vasilevich"}},
+			{row: 2, fio: FIO{Name: "Ivan", Surname: "Ivanov"}},
+		}
+		if len(rows) != len(expected) {
+			t.Fatalf("got %d rows, wanted %d", len(rows), len(expected))
+		}
+		for i := range expected {
+			if rows[i] != expected[i] {
+				t.Errorf("row %d: got %+v, wanted %+v", i, rows[i], expected[i])
+			}
+		}
+	})
+
+	t.Run("CSV without required column", func(t *testing.T) {
+		err := parseImport(strings.NewReader("name,patronymic\nIvan,Ivanovich\n"), ImportCSV,
+			func(int, FIO, string, error) error { return nil })
+		if err == nil {
+			t.Errorf("expected error, but got none")
+		}
+	})
+
+	t.Run("NDJSON with broken line", func(t *testing.T) {
+		data := "{\"name\": \"Ivan\", \"surname\": \"Ivanov\"}\n\nnot json\n{\"name\": \"Anna\", \"surname\": \"Smith\"}\n"
+		rows := collectRows(t, ImportNDJSON, data)
+
+		if len(rows) != 3 {
+			t.Fatalf("got %d rows, wanted 3", len(rows))
+		}
+		if !rows[1].hasErr || rows[1].row != 2 {
+			t.Errorf("expected error in row 2, got %+v", rows[1])
+		}
+		if rows[2].fio.Name != "Anna" {
+			t.Errorf("got name %q, wanted %q", rows[2].fio.Name, "Anna")
+		}
+	})
+}
+
+func TestRunImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImportRepo := mocks.NewMockImportRepo(ctrl)
+	f := &FIOService{importRepo: mockImportRepo}
+
+	mockImportRepo.EXPECT().UpdateImport(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	mockImportRepo.EXPECT().AddImportErrors(gomock.Any(), int64(3), gomock.Len(2)).Return(nil)
+
+	imp := model.Import{ID: 3, Format: ImportCSV, Mode: ImportModeEnrich}
+	imp = f.RunImport(context.Background(), imp, strings.NewReader("name,surname\n,Ivanov\nIvan,\n"))
+
+	if got, want := imp.Status, model.ImportCompleted; got != want {
+		t.Errorf("got status %q, wanted %q", got, want)
+	}
+	if imp.ProcessedRows != 2 || imp.FailedRows != 2 {
+		t.Errorf("got processed %d and failed %d, wanted 2 and 2", imp.ProcessedRows, imp.FailedRows)
+	}
+}
+
+func TestRunImportInterrupted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImportRepo := mocks.NewMockImportRepo(ctrl)
+	f := &FIOService{importRepo: mockImportRepo}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// состояние прерванного импорта сохраняется, несмотря на отмененный контекст
+	var saved model.Import
+	mockImportRepo.EXPECT().UpdateImport(gomock.Any(), gomock.Any()).Return(nil)
+	mockImportRepo.EXPECT().AddImportErrors(gomock.Any(), int64(3), gomock.Len(1)).
+		DoAndReturn(func(ctx context.Context, _ int64, _ []model.ImportRowError) error { return ctx.Err() })
+	mockImportRepo.EXPECT().UpdateImport(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, imp model.Import) error {
+			saved = imp
+			return ctx.Err()
+		})
+
+	imp := model.Import{ID: 3, Format: ImportCSV, Mode: ImportModeEnrich}
+	imp = f.RunImport(ctx, imp, strings.NewReader("name,surname\n,Ivanov\nIvan,\n"))
+
+	if got, want := saved.Status, model.ImportFailed; got != want {
+		t.Errorf("got saved status %q, wanted %q", got, want)
+	}
+	if imp.ProcessedRows != 1 {
+		t.Errorf("got processed %d, wanted 1", imp.ProcessedRows)
+	}
+}
+
+func TestStopCancelsImports(t *testing.T) {
+	f := &FIOService{stopCh: make(chan bool)}
+
+	stopped := make(chan struct{})
+	if !f.goImport(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	}) {
+		t.Fatal("import was not started")
+	}
+
+	f.Stop()
+	if !f.WaitImports(time.Second) {
+		t.Fatal("import did not stop")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("WaitImports returned before import finished")
+	}
+
+	if f.goImport(context.Background(), func(context.Context) {}) {
+		t.Error("import started after Stop")
+	}
+}
+
+func TestGetImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImportRepo := mocks.NewMockImportRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{importRepo: mockImportRepo}
+
+	mockImportRepo.EXPECT().GetImport(gomock.Any(), int64(42), 1, defaultImportErrorsSize).
+		Return(model.Import{}, repo.ErrImportNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("42")
+
+	handle(c, f.GetImport)
+
+	if got, want := rec.Code, http.StatusNotFound; got != want {
+		t.Errorf("got status %d, wanted %d", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: user-service/repo (interfaces: APIKeyRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	model "user-service/api_clients/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAPIKeyRepo is a mock of APIKeyRepo interface.
+type MockAPIKeyRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyRepoMockRecorder
+}
+
+// MockAPIKeyRepoMockRecorder is the mock recorder for MockAPIKeyRepo.
+type MockAPIKeyRepoMockRecorder struct {
+	mock *MockAPIKeyRepo
+}
+
+// NewMockAPIKeyRepo creates a new mock instance.
+func NewMockAPIKeyRepo(ctrl *gomock.Controller) *MockAPIKeyRepo {
+	mock := &MockAPIKeyRepo{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyRepo) EXPECT() *MockAPIKeyRepoMockRecorder {
+	return m.recorder
+}
+
+// AddAPIKeyUsage mocks base method.
+func (m *MockAPIKeyRepo) AddAPIKeyUsage(arg0 context.Context, arg1 []model.APIKeyUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKeyUsage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAPIKeyUsage indicates an expected call of AddAPIKeyUsage.
+func (mr *MockAPIKeyRepoMockRecorder) AddAPIKeyUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKeyUsage", reflect.TypeOf((*MockAPIKeyRepo)(nil).AddAPIKeyUsage), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyRepo) CreateAPIKey(arg0 context.Context, arg1 model.APIKey, arg2 string) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyRepoMockRecorder) CreateAPIKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyRepo)(nil).CreateAPIKey), arg0, arg1, arg2)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockAPIKeyRepo) GetAPIKeyByHash(arg0 context.Context, arg1 string) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", arg0, arg1)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockAPIKeyRepoMockRecorder) GetAPIKeyByHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockAPIKeyRepo)(nil).GetAPIKeyByHash), arg0, arg1)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockAPIKeyRepo) ListAPIKeys(arg0 context.Context) ([]model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", arg0)
+	ret0, _ := ret[0].([]model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockAPIKeyRepoMockRecorder) ListAPIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockAPIKeyRepo)(nil).ListAPIKeys), arg0)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyRepo) RevokeAPIKey(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyRepoMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyRepo)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// RotateAPIKey mocks base method.
+func (m *MockAPIKeyRepo) RotateAPIKey(arg0 context.Context, arg1 int64, arg2, arg3 string) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateAPIKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAPIKey indicates an expected call of RotateAPIKey.
+func (mr *MockAPIKeyRepoMockRecorder) RotateAPIKey(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAPIKey", reflect.TypeOf((*MockAPIKeyRepo)(nil).RotateAPIKey), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: user-service/repo (interfaces: ImportRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	model "user-service/api_clients/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockImportRepo is a mock of ImportRepo interface.
+type MockImportRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportRepoMockRecorder
+}
+
+// MockImportRepoMockRecorder is the mock recorder for MockImportRepo.
+type MockImportRepoMockRecorder struct {
+	mock *MockImportRepo
+}
+
+// NewMockImportRepo creates a new mock instance.
+func NewMockImportRepo(ctrl *gomock.Controller) *MockImportRepo {
+	mock := &MockImportRepo{ctrl: ctrl}
+	mock.recorder = &MockImportRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportRepo) EXPECT() *MockImportRepoMockRecorder {
+	return m.recorder
+}
+
+// AddImportErrors mocks base method.
+func (m *MockImportRepo) AddImportErrors(arg0 context.Context, arg1 int64, arg2 []model.ImportRowError) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddImportErrors", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddImportErrors indicates an expected call of AddImportErrors.
+func (mr *MockImportRepoMockRecorder) AddImportErrors(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddImportErrors", reflect.TypeOf((*MockImportRepo)(nil).AddImportErrors), arg0, arg1, arg2)
+}
+
+// CreateImport mocks base method.
+func (m *MockImportRepo) CreateImport(arg0 context.Context, arg1 model.Import) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateImport", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateImport indicates an expected call of CreateImport.
+func (mr *MockImportRepoMockRecorder) CreateImport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateImport", reflect.TypeOf((*MockImportRepo)(nil).CreateImport), arg0, arg1)
+}
+
+// GetImport mocks base method.
+func (m *MockImportRepo) GetImport(arg0 context.Context, arg1 int64, arg2, arg3 int) (model.Import, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImport", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(model.Import)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImport indicates an expected call of GetImport.
+func (mr *MockImportRepoMockRecorder) GetImport(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImport", reflect.TypeOf((*MockImportRepo)(nil).GetImport), arg0, arg1, arg2, arg3)
+}
+
+// UpdateImport mocks base method.
+func (m *MockImportRepo) UpdateImport(arg0 context.Context, arg1 model.Import) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateImport", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateImport indicates an expected call of UpdateImport.
+func (mr *MockImportRepoMockRecorder) UpdateImport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateImport", reflect.TypeOf((*MockImportRepo)(nil).UpdateImport), arg0, arg1)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: user-service/repo (interfaces: OutboxRepo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	model "user-service/api_clients/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOutboxRepo is a mock of OutboxRepo interface.
+type MockOutboxRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepoMockRecorder
+}
+
+// MockOutboxRepoMockRecorder is the mock recorder for MockOutboxRepo.
+type MockOutboxRepoMockRecorder struct {
+	mock *MockOutboxRepo
+}
+
+// NewMockOutboxRepo creates a new mock instance.
+func NewMockOutboxRepo(ctrl *gomock.Controller) *MockOutboxRepo {
+	mock := &MockOutboxRepo{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepo) EXPECT() *MockOutboxRepoMockRecorder {
+	return m.recorder
+}
+
+// RelayOutbox mocks base method.
+func (m *MockOutboxRepo) RelayOutbox(arg0 context.Context, arg1 int, arg2 func([]model.OutboxEvent) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelayOutbox", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelayOutbox indicates an expected call of RelayOutbox.
+func (mr *MockOutboxRepoMockRecorder) RelayOutbox(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelayOutbox", reflect.TypeOf((*MockOutboxRepo)(nil).RelayOutbox), arg0, arg1, arg2)
+}
@@ -7,7 +7,9 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 	model "user-service/api_clients/model"
+	repo "user-service/repo"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -36,47 +38,179 @@ func (m *MockUserRepo) EXPECT() *MockUserRepoMockRecorder {
 }
 
 // AddUser mocks base method.
-func (m *MockUserRepo) AddUser(arg0 model.User) (int, error) {
+func (m *MockUserRepo) AddUser(arg0 context.Context, arg1 model.User) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUser", arg0)
+	ret := m.ctrl.Call(m, "AddUser", arg0, arg1)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddUser indicates an expected call of AddUser.
-func (mr *MockUserRepoMockRecorder) AddUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserRepoMockRecorder) AddUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockUserRepo)(nil).AddUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockUserRepo)(nil).AddUser), arg0, arg1)
+}
+
+// ApplyBatch mocks base method.
+func (m *MockUserRepo) ApplyBatch(arg0 context.Context, arg1 []model.BatchOperation, arg2 bool) ([]repo.BatchOpResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]repo.BatchOpResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyBatch indicates an expected call of ApplyBatch.
+func (mr *MockUserRepoMockRecorder) ApplyBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyBatch", reflect.TypeOf((*MockUserRepo)(nil).ApplyBatch), arg0, arg1, arg2)
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserRepo) DeleteUser(arg0 int) error {
+func (m *MockUserRepo) DeleteUser(arg0 context.Context, arg1 int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", arg0)
+	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserRepoMockRecorder) DeleteUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserRepoMockRecorder) DeleteUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepo)(nil).DeleteUser), arg0, arg1)
+}
+
+// FindDuplicateCandidates mocks base method.
+func (m *MockUserRepo) FindDuplicateCandidates(arg0 context.Context, arg1 repo.DuplicateFilter) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicateCandidates", arg0, arg1)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDuplicateCandidates indicates an expected call of FindDuplicateCandidates.
+func (mr *MockUserRepoMockRecorder) FindDuplicateCandidates(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicateCandidates", reflect.TypeOf((*MockUserRepo)(nil).FindDuplicateCandidates), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockUserRepo) GetUser(arg0 context.Context, arg1 int) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserRepoMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepo)(nil).DeleteUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserRepo)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserHistory mocks base method.
+func (m *MockUserRepo) GetUserHistory(arg0 context.Context, arg1, arg2, arg3 int) ([]model.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserHistory", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserHistory indicates an expected call of GetUserHistory.
+func (mr *MockUserRepoMockRecorder) GetUserHistory(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserHistory", reflect.TypeOf((*MockUserRepo)(nil).GetUserHistory), arg0, arg1, arg2, arg3)
 }
 
 // GetUsers mocks base method.
-func (m *MockUserRepo) GetUsers(arg0, arg1 int, arg2 string) ([]model.User, error) {
+func (m *MockUserRepo) GetUsers(arg0 context.Context, arg1, arg2 int, arg3 repo.UserFilter) ([]model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserRepoMockRecorder) GetUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockUserRepoMockRecorder) GetUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserRepo)(nil).GetUsers), arg0, arg1, arg2, arg3)
+}
+
+// MergeUsers mocks base method.
+func (m *MockUserRepo) MergeUsers(arg0 context.Context, arg1 int, arg2 []int) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeUsers indicates an expected call of MergeUsers.
+func (mr *MockUserRepoMockRecorder) MergeUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeUsers", reflect.TypeOf((*MockUserRepo)(nil).MergeUsers), arg0, arg1, arg2)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockUserRepo) PurgeDeleted(arg0 context.Context, arg1 time.Time) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", arg0, arg1)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockUserRepoMockRecorder) PurgeDeleted(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserRepo)(nil).GetUsers), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockUserRepo)(nil).PurgeDeleted), arg0, arg1)
+}
+
+// PurgeUser mocks base method.
+func (m *MockUserRepo) PurgeUser(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeUser indicates an expected call of PurgeUser.
+func (mr *MockUserRepoMockRecorder) PurgeUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUser", reflect.TypeOf((*MockUserRepo)(nil).PurgeUser), arg0, arg1)
+}
+
+// RecordAccessDenied mocks base method.
+func (m *MockUserRepo) RecordAccessDenied(arg0 context.Context, arg1 model.AccessDenial) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAccessDenied", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordAccessDenied indicates an expected call of RecordAccessDenied.
+func (mr *MockUserRepoMockRecorder) RecordAccessDenied(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAccessDenied", reflect.TypeOf((*MockUserRepo)(nil).RecordAccessDenied), arg0, arg1)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserRepo) RestoreUser(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserRepoMockRecorder) RestoreUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserRepo)(nil).RestoreUser), arg0, arg1)
 }
 
 // Save mocks base method.
@@ -93,16 +227,60 @@ func (mr *MockUserRepoMockRecorder) Save(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockUserRepo)(nil).Save), arg0, arg1)
 }
 
+// SearchUsers mocks base method.
+func (m *MockUserRepo) SearchUsers(arg0 context.Context, arg1 repo.SearchQuery) ([]model.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", arg0, arg1)
+	ret0, _ := ret[0].([]model.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockUserRepoMockRecorder) SearchUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockUserRepo)(nil).SearchUsers), arg0, arg1)
+}
+
+// StreamUsers mocks base method.
+func (m *MockUserRepo) StreamUsers(arg0 context.Context, arg1 repo.UserFilter, arg2 func(model.User) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamUsers indicates an expected call of StreamUsers.
+func (mr *MockUserRepoMockRecorder) StreamUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamUsers", reflect.TypeOf((*MockUserRepo)(nil).StreamUsers), arg0, arg1, arg2)
+}
+
 // UpdateUser mocks base method.
-func (m *MockUserRepo) UpdateUser(arg0 model.User) error {
+func (m *MockUserRepo) UpdateUser(arg0 context.Context, arg1 model.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", arg0)
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserRepoMockRecorder) UpdateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserRepoMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepo)(nil).UpdateUser), arg0, arg1)
+}
+
+// UserStats mocks base method.
+func (m *MockUserRepo) UserStats(arg0 context.Context, arg1 repo.UserFilter, arg2 int) (model.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserStats", arg0, arg1, arg2)
+	ret0, _ := ret[0].(model.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserStats indicates an expected call of UserStats.
+func (mr *MockUserRepoMockRecorder) UserStats(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepo)(nil).UpdateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserStats", reflect.TypeOf((*MockUserRepo)(nil).UserStats), arg0, arg1, arg2)
 }
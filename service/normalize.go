@@ -0,0 +1,61 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"user-service/api_clients/model"
+	"user-service/pkg/translit"
+)
+
+// Transliteration настраивает латиницу для обогащения и поиска
+type Transliteration struct {
+	// Scheme - схема для ключа запросов к agify, genderize и nationalize, nil - имя передается как есть
+	Scheme *translit.Scheme
+	// StoreLatin - сохранять ФИО латиницей по Scheme в колонку latin для поиска
+	StoreLatin bool
+}
+
+// normalizeFIO приводит части ФИО к каноническому виду до валидации
+func (f *FIOService) normalizeFIO(fio FIO) FIO {
+	return FIO{
+		Name:       f.names.Name(fio.Name),
+		Surname:    f.names.Name(fio.Surname),
+		Patronymic: f.names.Name(fio.Patronymic),
+	}
+}
+
+// normalizeUser нормализует ФИО пользователя из REST API, исходное ФИО сохраняется в RawInput.
+// Пол приводится к нижнему регистру, код страны - к верхнему.
+func (f *FIOService) normalizeUser(user *model.User) {
+	raw := FIO{Name: user.Name, Surname: user.Surname, Patronymic: user.Patronymic}
+	fio := f.normalizeFIO(raw)
+	user.Name, user.Surname, user.Patronymic = fio.Name, fio.Surname, fio.Patronymic
+	user.Gender = strings.ToLower(strings.TrimSpace(user.Gender))
+	user.Nationality = strings.ToUpper(strings.TrimSpace(user.Nationality))
+	user.RawInput = rawFIO(raw)
+	user.Latin = f.latinFIO(fio)
+}
+
+// rawFIO возвращает исходное ФИО для колонки raw_input
+func rawFIO(raw FIO) json.RawMessage {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// latinFIO возвращает ФИО латиницей в порядке фамилия, имя, отчество или пустую строку,
+// если хранение латиницы выключено
+func (f *FIOService) latinFIO(fio FIO) string {
+	if !f.latin.StoreLatin || f.latin.Scheme == nil {
+		return ""
+	}
+	parts := make([]string, 0, 3)
+	for _, part := range []string{fio.Surname, fio.Name, fio.Patronymic} {
+		if part != "" {
+			parts = append(parts, f.latin.Scheme.String(part))
+		}
+	}
+	return strings.Join(parts, " ")
+}
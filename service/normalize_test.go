@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"user-service/api_clients/model"
+	"user-service/pkg/translit"
+)
+
+func TestNormalizeUser(t *testing.T) {
+	t.Run("Latin is stored when enabled", func(t *testing.T) {
+		f := &FIOService{latin: Transliteration{Scheme: translit.ICAO, StoreLatin: true}}
+		user := model.User{Name: "дмитрий", Surname: " ЩУКИН", Patronymic: "юрьевич"}
+
+		f.normalizeUser(&user)
+
+		if got, want := user.Name, "Дмитрий"; got != want {
+			t.Errorf("got name %q, wanted %q", got, want)
+		}
+		if got, want := user.Latin, "Shchukin Dmitrii Iurevich"; got != want {
+			t.Errorf("got latin %q, wanted %q", got, want)
+		}
+	})
+
+	t.Run("Latin is not stored by default", func(t *testing.T) {
+		f := &FIOService{latin: Transliteration{Scheme: translit.ICAO}}
+		user := model.User{Name: "Дмитрий", Surname: "Щукин"}
+
+		f.normalizeUser(&user)
+
+		if user.Latin != "" {
+			t.Errorf("got latin %q, wanted none", user.Latin)
+		}
+	})
+}
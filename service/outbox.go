@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"time"
+	"user-service/api_clients/model"
+	"user-service/pkg/kafka"
+)
+
+// DefaultOutboxBatchSize - сколько событий публикуется за один проход, если размер не задан
+const DefaultOutboxBatchSize = 100
+
+// DefaultOutboxRelayInterval - период публикации, если интервал не задан или не положителен
+const DefaultOutboxRelayInterval = time.Second
+
+// RunOutboxRelay раз в interval публикует события outbox в топик Kafka, пока очередь не опустеет.
+// Событие публикуется не реже одного раза: если отметка о публикации не сохранилась, оно уйдет повторно.
+// В заголовках сообщения - контекст трассировки и идентификатор запроса, в котором событие записано.
+// Работает до вызова Stop.
+func (f *FIOService) RunOutboxRelay(topic string, interval time.Duration, batchSize int) {
+	if f.outboxRepo == nil {
+		return
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultOutboxBatchSize
+	}
+	if interval <= 0 {
+		interval = DefaultOutboxRelayInterval
+	}
+	publish := func(envelopes []kafka.Envelope) error {
+		return f.kafkaService.PublishEnvelopes(context.Background(), topic, envelopes)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopCh:
+			return
+		case <-ticker.C:
+			if _, err := f.relayOutbox(context.Background(), batchSize, publish); err != nil {
+				log.Error("Failed to relay outbox events:", err)
+			}
+		}
+	}
+}
+
+// relayOutbox публикует события порциями по batchSize, пока порция заполнена целиком, и возвращает их число
+func (f *FIOService) relayOutbox(ctx context.Context, batchSize int, publish func([]kafka.Envelope) error) (int, error) {
+	total := 0
+	for {
+		n, err := f.outboxRepo.RelayOutbox(ctx, batchSize, func(events []model.OutboxEvent) error {
+			envelopes := make([]kafka.Envelope, len(events))
+			for i, event := range events {
+				data, err := json.Marshal(event)
+				if err != nil {
+					return err
+				}
+				envelopes[i] = kafka.Envelope{Value: data, Headers: event.Headers}
+			}
+			return publish(envelopes)
+		})
+		total += n
+		if err != nil || n < batchSize {
+			return total, err
+		}
+	}
+}
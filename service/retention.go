@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	log "github.com/sirupsen/logrus"
+	"time"
+	"user-service/repo"
+)
+
+// RunRetention раз в interval окончательно удаляет пользователей, мягко удаленных более period назад.
+// Работает до вызова Stop.
+func (f *FIOService) RunRetention(period, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stopCh:
+			return
+		case <-ticker.C:
+			ctx := repo.WithActor(context.Background(), repo.Actor{Name: "retention-job", Source: repo.SourceRetention})
+			purged, err := f.userRepo.PurgeDeleted(ctx, time.Now().Add(-period))
+			if err != nil {
+				log.Error("Failed to purge deleted users:", err)
+				continue
+			}
+			if len(purged) > 0 {
+				f.invalidateUsers(ctx, purged...)
+				log.Infof("Purged %d deleted users", len(purged))
+			}
+		}
+	}
+}
+
+// Stop останавливает фоновые циклы сервиса
+func (f *FIOService) Stop() {
+	// под importsMu, чтобы goImport не запустил импорт, которого уже не дождется WaitImports
+	f.importsMu.Lock()
+	defer f.importsMu.Unlock()
+	close(f.stopCh)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"user-service/pkg/similarity"
+	"user-service/repo"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Параметры поиска по умолчанию
+const (
+	DefaultSearchThreshold = 0.3
+	defaultSearchSize      = 20
+	maxSearchSize          = 100
+	minSearchQuery         = 2
+	// highlightSimilarity - сходство слова с термином запроса, при котором слово с опечаткой выделяется целиком
+	highlightSimilarity = 0.85
+)
+
+// SearchUsers ищет пользователей по полному ФИО с опечатками и в любом порядке слов.
+// Результаты упорядочены по сходству, совпавшие фрагменты ФИО выделены в highlight.
+func (f *FIOService) SearchUsers(c echo.Context) error {
+	q := strings.ToLower(strings.Join(strings.Fields(c.QueryParam("q")), " "))
+	if utf8.RuneCountInString(q) < minSearchQuery {
+		err := InvalidParameter("q")
+		err.Fields[0].Message = "must be at least " + strconv.Itoa(minSearchQuery) + " characters"
+		return err
+	}
+
+	page, size, threshold := 1, defaultSearchSize, DefaultSearchThreshold
+	var err error
+	if pageStr := c.QueryParam("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 1 {
+			return InvalidParameter("page")
+		}
+	}
+	if sizeStr := c.QueryParam("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size < 1 || size > maxSearchSize {
+			return InvalidParameter("size")
+		}
+	}
+	if thresholdStr := c.QueryParam("threshold"); thresholdStr != "" {
+		threshold, err = strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return InvalidParameter("threshold")
+		}
+	}
+
+	results, err := f.userRepo.SearchUsers(c.Request().Context(), repo.SearchQuery{
+		Text:      q,
+		Threshold: threshold,
+		Limit:     size,
+		Offset:    (page - 1) * size,
+	})
+	if err != nil {
+		return Internal("Failed to search users", err)
+	}
+
+	terms := searchTerms(q)
+	for i := range results {
+		user := results[i].User
+		highlight := make(map[string]string)
+		for field, value := range map[string]string{
+			"surname":    user.Surname,
+			"name":       user.Name,
+			"patronymic": user.Patronymic,
+			"latin":      user.Latin,
+		} {
+			if marked, ok := highlightField(value, terms); ok {
+				highlight[field] = marked
+			}
+		}
+		results[i].Highlight = highlight
+	}
+	return c.JSON(http.StatusOK, results)
+}
+
+// searchTerms - слова запроса, по которым выделяются совпадения
+func searchTerms(q string) [][]rune {
+	var terms [][]rune
+	for _, word := range strings.Fields(q) {
+		if utf8.RuneCountInString(word) >= minSearchQuery {
+			terms = append(terms, []rune(word))
+		}
+	}
+	return terms
+}
+
+// highlightField оборачивает в <em> совпавшие с запросом фрагменты: вхождение термина как подстроки
+// выделяется точно, слово с опечаткой - целиком. Возвращает false, если выделять нечего.
+func highlightField(value string, terms [][]rune) (string, bool) {
+	runes := []rune(value)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	found := false
+	for start := 0; start < len(runes); {
+		if unicode.IsSpace(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+
+		word := lower[start:end]
+		for _, term := range terms {
+			if idx := indexRunes(word, term); idx >= 0 {
+				mark(marked, start+idx, start+idx+len(term))
+				found = true
+			} else if similarity.JaroWinkler(string(word), string(term)) >= highlightSimilarity {
+				mark(marked, start, end)
+				found = true
+			}
+		}
+		start = end
+	}
+	if !found {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		text := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			text = "<em>" + text + "</em>"
+		}
+		b.WriteString(text)
+		i = j
+	}
+	return b.String(), true
+}
+
+func mark(marked []bool, from, to int) {
+	for i := from; i < to; i++ {
+		marked[i] = true
+	}
+}
+
+func indexRunes(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
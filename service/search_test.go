@@ -0,0 +1,119 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-service/api_clients/model"
+	"user-service/repo"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestSearchUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	search := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users/search?"+query, nil)
+		rec := httptest.NewRecorder()
+		handle(e.NewContext(req, rec), f.SearchUsers)
+		return rec
+	}
+
+	t.Run("Results keep ranking and are highlighted", func(t *testing.T) {
+		mockUserRepo.EXPECT().SearchUsers(gomock.Any(), repo.SearchQuery{
+			Text: "иванов петр", Threshold: DefaultSearchThreshold, Limit: 20, Offset: 20,
+		}).Return([]model.SearchResult{
+			{User: model.User{ID: 2, Name: "Пётр", Surname: "Иванов"}, Score: 0.8},
+			{User: model.User{ID: 1, Name: "Петра", Surname: "Иваново-<Воз>"}, Score: 0.5},
+		}, nil)
+
+		rec := search("q=%20%20Иванов%20%20Петр&page=2")
+
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("got status %d, wanted %d", got, want)
+		}
+		var results []model.SearchResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 || results[0].User.ID != 2 || results[1].User.ID != 1 {
+			t.Fatalf("unexpected results %+v", results)
+		}
+		expected := []map[string]string{
+			{"surname": "<em>Иванов</em>", "name": "<em>Пётр</em>"},
+			{"surname": "<em>Иванов</em>о-&lt;Воз&gt;", "name": "<em>Петр</em>а"},
+		}
+		for i, highlight := range expected {
+			for field, want := range highlight {
+				if got := results[i].Highlight[field]; got != want {
+					t.Errorf("result %d: got %s highlight %q, wanted %q", i, field, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("One-word query", func(t *testing.T) {
+		mockUserRepo.EXPECT().SearchUsers(gomock.Any(), repo.SearchQuery{
+			Text: "иванов", Threshold: DefaultSearchThreshold, Limit: 20,
+		}).Return([]model.SearchResult{
+			{User: model.User{ID: 3, Name: "Константин", Surname: "Иванов", Patronymic: "Александрович"}, Score: 1},
+		}, nil)
+
+		rec := search("q=Иванов")
+
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("got status %d, wanted %d", got, want)
+		}
+		var results []model.SearchResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].User.ID != 3 {
+			t.Fatalf("unexpected results %+v", results)
+		}
+		if got, want := results[0].Highlight["surname"], "<em>Иванов</em>"; got != want {
+			t.Errorf("got surname highlight %q, wanted %q", got, want)
+		}
+		if _, ok := results[0].Highlight["name"]; ok {
+			t.Errorf("unexpected name highlight %q", results[0].Highlight["name"])
+		}
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		repoErr  error
+		callRepo bool
+		expected int
+	}{
+		{name: "Query is required", query: "q=%20", expected: http.StatusBadRequest},
+		{name: "Query is too short", query: "q=и", expected: http.StatusBadRequest},
+		{name: "Invalid size", query: "q=иванов&size=101", expected: http.StatusBadRequest},
+		{name: "Invalid threshold", query: "q=иванов&threshold=-1", expected: http.StatusBadRequest},
+		{name: "Failed to search", query: "q=иванов", repoErr: errors.New("DB error"), callRepo: true, expected: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.callRepo {
+				mockUserRepo.EXPECT().SearchUsers(gomock.Any(), gomock.Any()).Return(nil, test.repoErr)
+			}
+
+			rec := search(test.query)
+
+			if got, want := rec.Code, test.expected; got != want {
+				t.Errorf("got status %d, wanted %d", got, want)
+			}
+		})
+	}
+}
@@ -1,13 +1,33 @@
 package service
 
 import (
+	"context"
 	"github.com/labstack/echo/v4"
+	"user-service/pkg/auth"
 )
 
 type FIOServiceInterface interface {
 	ProcessMessages()
 	GetUsers(c echo.Context) error
+	SearchUsers(c echo.Context) error
+	GetUserStats(c echo.Context) error
+	GetUser(c echo.Context) error
 	AddUser(c echo.Context) error
 	DeleteUser(c echo.Context) error
 	UpdateUser(c echo.Context) error
+	RestoreUser(c echo.Context) error
+	PurgeUser(c echo.Context) error
+	GetUserHistory(c echo.Context) error
+	GetDuplicates(c echo.Context) error
+	MergeUsers(c echo.Context) error
+	BatchUsers(c echo.Context) error
+	ExportUsers(c echo.Context) error
+	CreateImport(c echo.Context) error
+	GetImport(c echo.Context) error
+	Forbidden(c echo.Context, permission string) error
+	IssueAPIKey(c echo.Context) error
+	ListAPIKeys(c echo.Context) error
+	RotateAPIKey(c echo.Context) error
+	RevokeAPIKey(c echo.Context) error
+	AuthenticateAPIKey(ctx context.Context, secret string) (auth.Claims, error)
 }
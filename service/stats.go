@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultAgeBucket - ширина возрастной группы в годах, если она не задана
+const DefaultAgeBucket = 10
+
+// Stats настраивает статистику по пользователям
+type Stats struct {
+	// AgeBucket - ширина возрастной группы в годах, запрос может переопределить ее параметром bucket
+	AgeBucket int
+}
+
+// GetUserStats возвращает агрегаты по пользователям с теми же фильтрами, что у списка.
+// Результат кэшируется вместе со страницами списка и сбрасывается при любом изменении пользователей.
+func (f *FIOService) GetUserStats(c echo.Context) error {
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		return err
+	}
+
+	bucket := f.stats.AgeBucket
+	if bucket <= 0 {
+		bucket = DefaultAgeBucket
+	}
+	if bucketStr := c.QueryParam("bucket"); bucketStr != "" {
+		bucket, err = strconv.Atoi(bucketStr)
+		if err != nil || bucket < 1 || bucket > MaxAge {
+			return InvalidParameter("bucket")
+		}
+	}
+
+	ctx := c.Request().Context()
+	gen, cacheable := f.usersGeneration(ctx)
+	cacheKey := usersStatsCacheKey(gen, bucket, filter.Name, filter.IncludeDeleted)
+
+	data, err := f.loadCached(ctx, cacheKey, cacheable, func(ctx context.Context) ([]byte, error) {
+		stats, err := f.userRepo.UserStats(ctx, filter, bucket)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(stats)
+	})
+	if err != nil {
+		return Internal("Failed to compute user stats", err)
+	}
+
+	return c.JSONBlob(http.StatusOK, data)
+}
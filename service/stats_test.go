@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-service/api_clients/model"
+	"user-service/pkg/cache"
+	"user-service/repo"
+	"user-service/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+)
+
+func TestGetUserStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	store := newTestRedis(t)
+	f := &FIOService{userRepo: mockUserRepo, store: store, cache: cache.NewReadThrough(store, store), stats: Stats{AgeBucket: 5}}
+
+	stats := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users/stats?"+query, nil)
+		rec := httptest.NewRecorder()
+		handle(e.NewContext(req, rec), f.GetUserStats)
+		return rec
+	}
+
+	t.Run("Stats are cached per filter and bucket", func(t *testing.T) {
+		filter := repo.UserFilter{Name: "Iv"}
+		mockUserRepo.EXPECT().UserStats(gomock.Any(), filter, 5).Return(model.UserStats{Total: 3, BucketWidth: 5}, nil).Times(1)
+		mockUserRepo.EXPECT().UserStats(gomock.Any(), filter, 20).Return(model.UserStats{Total: 3, BucketWidth: 20}, nil).Times(1)
+
+		for _, query := range []string{"filter=Iv", "filter=Iv", "filter=Iv&bucket=20", "filter=Iv&bucket=20"} {
+			if got, want := stats(query).Code, http.StatusOK; got != want {
+				t.Fatalf("%s: got status %d, wanted %d", query, got, want)
+			}
+		}
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		repoErr  error
+		callRepo bool
+		expected int
+	}{
+		{name: "Invalid bucket", query: "bucket=0", expected: http.StatusBadRequest},
+		{name: "Invalid include_deleted", query: "include_deleted=maybe", expected: http.StatusBadRequest},
+		{name: "Failed to compute", query: "filter=Pe", repoErr: errors.New("DB error"), callRepo: true, expected: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.callRepo {
+				mockUserRepo.EXPECT().UserStats(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.UserStats{}, test.repoErr)
+			}
+
+			if got, want := stats(test.query).Code, test.expected; got != want {
+				t.Errorf("got status %d, wanted %d", got, want)
+			}
+		})
+	}
+}
@@ -3,23 +3,49 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/go-redis/redis/v8"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 	"user-service/api_clients/model"
+	"user-service/pkg/cache"
 	"user-service/pkg/kafka"
+	"user-service/pkg/logger"
+	"user-service/pkg/metrics"
+	"user-service/pkg/normalize"
 	"user-service/repo"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracerScope - имя библиотеки инструментирования сервиса в спанах
+const tracerScope = "user-service/service"
+
 type FIOService struct {
 	kafkaService *kafka.Service
 	userRepo     repo.UserRepo
-	RedisClient  *redis.Client
+	importRepo   repo.ImportRepo
+	apiKeyRepo   repo.APIKeyRepo
+	outboxRepo   repo.OutboxRepo
+	apiKeys      *cache.LRU
+	apiKeyUsage  *apiKeyUsage
+	names        *normalize.Normalizer
+	latin        Transliteration
+	duplicates   Duplicates
+	stats        Stats
+	store        cache.Cache
+	cache        cache.Loader
 	stopCh       chan bool
+	importsMu    sync.Mutex
+	imports      sync.WaitGroup
 }
 
 type FIO struct {
@@ -31,12 +57,24 @@ type FIO struct {
 // устанавливаем срок жизни кэша
 const CacheExpiration = 5 * time.Minute
 
-func NewFIOService(kafkaService *kafka.Service, userRepo repo.UserRepo, rdb *redis.Client) *FIOService {
+func NewFIOService(kafkaService *kafka.Service, userRepo repo.UserRepo, importRepo repo.ImportRepo, apiKeyRepo repo.APIKeyRepo,
+	outboxRepo repo.OutboxRepo, store cache.Cache, loader cache.Loader, names *normalize.Normalizer, latin Transliteration,
+	duplicates Duplicates, stats Stats) *FIOService {
 	return &FIOService{
 		kafkaService: kafkaService,
 		userRepo:     userRepo,
+		importRepo:   importRepo,
+		apiKeyRepo:   apiKeyRepo,
+		outboxRepo:   outboxRepo,
+		apiKeys:      cache.NewLRU(apiKeyCacheSize, 0),
+		apiKeyUsage:  newAPIKeyUsage(),
+		names:        names,
+		latin:        latin,
+		duplicates:   duplicates,
+		stats:        stats,
 		stopCh:       make(chan bool),
-		RedisClient:  rdb,
+		store:        store,
+		cache:        loader,
 	}
 }
 
@@ -67,55 +105,131 @@ func (f *FIOService) ProcessMessages() {
 			if err != nil {
 				// Обрабатываем ошибку чтения из Kafka
 				log.Error("Failed to read message from Kafka:", err)
+				metrics.ConsumerFailed.WithLabelValues(metrics.ReasonRead).Inc()
 				continue
 			}
+			metrics.ConsumerLag.WithLabelValues(strconv.Itoa(msg.Partition)).Set(float64(msg.HighWaterMark - msg.Offset - 1))
+			f.processMessage(msg)
+		}
+	}
+}
 
-			// Десериализация сообщения
-			var fioMessage FIO
-			err = json.Unmarshal(msg.Value, &fioMessage)
-			if err != nil {
-				// Отправляем сообщение в очередь FIO_FAILED
-				if pubErr := f.kafkaService.PublishToTopic("FIO_FAILED", msg.Value); pubErr != nil {
-					log.Error("Failed to publish message to FIO_FAILED queue:", pubErr)
-				}
-				continue
-			}
+// messageRequestID берет идентификатор запроса из заголовка сообщения, а если его нет
+// или он недопустим, создает новый
+func messageRequestID(headers kafka.HeaderCarrier) string {
+	if id := headers.Get(logger.HeaderRequestID); logger.ValidRequestID(id) {
+		return id
+	}
+	return logger.NewRequestID()
+}
 
-			// Валидация сообщения
-			err = fioMessage.IsValid()
-			if err != nil {
-				errorMsg := map[string]interface{}{
-					"error":            err.Error(),
-					"original_message": fioMessage,
-				}
-				errorBytes, marshalErr := json.Marshal(errorMsg)
-				if marshalErr != nil {
-					log.Error("Failed to marshal error message:", marshalErr)
-					continue
-				}
-				// Отправляем сообщение об ошибке в очередь FIO_FAILED
-				if pubErr := f.kafkaService.PublishToTopic("FIO_FAILED", errorBytes); pubErr != nil {
-					log.Error("Failed to publish message to FIO_FAILED queue:", pubErr)
-				}
-				continue
-			}
+// processMessage валидирует, обогащает и сохраняет одно сообщение. Трасса продолжается из заголовка
+// traceparent сообщения, поэтому запросы обогащения и запись в базу видны в трассе отправителя.
+// Идентификатор запроса берется из допустимого заголовка X-Request-ID или создается, строки лога отмечаются им
+// и смещением сообщения.
+func (f *FIOService) processMessage(msg kafkago.Message) {
+	headers := kafka.HeaderCarrier{Headers: &msg.Headers}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), headers)
+	ctx = logger.WithRequestID(ctx, messageRequestID(headers))
+	ctx = logger.WithFields(ctx, log.Fields{
+		"kafka_topic":     msg.Topic,
+		"kafka_partition": msg.Partition,
+		"kafka_offset":    msg.Offset,
+	})
+	ctx, span := otel.Tracer(tracerScope).Start(ctx, msg.Topic+" process", trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingOperationProcess,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingKafkaDestinationPartition(msg.Partition),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		))
+	defer span.End()
 
-			// Обогащение информации
-			enrichedData, err := f.enrichFIOData(fioMessage)
-			if err != nil {
-				log.Error("Failed to enrich the FIO data:", err)
-				continue
-			}
+	// Десериализация сообщения
+	var fioMessage FIO
+	err := json.Unmarshal(msg.Value, &fioMessage)
+	if err != nil {
+		metrics.ConsumerFailed.WithLabelValues(metrics.ReasonDecode).Inc()
+		span.SetStatus(codes.Error, metrics.ReasonDecode)
+		// Отправляем сообщение в очередь FIO_FAILED
+		logger.FromContext(ctx).Warn("Failed to decode message:", err)
+		if pubErr := f.kafkaService.PublishToTopic(ctx, "FIO_FAILED", msg.Value); pubErr != nil {
+			logger.FromContext(ctx).Error("Failed to publish message to FIO_FAILED queue:", pubErr)
+		}
+		return
+	}
 
-			// Сохранение в БД
-			ctx := context.Background()
-			user := convertToUser(enrichedData)
-			err = f.userRepo.Save(ctx, user)
-			if err != nil {
-				log.Error("Failed to save user to the database:", err)
-			}
+	// Нормализация и валидация сообщения, исходное ФИО сохраняется в raw_input
+	fio := f.normalizeFIO(fioMessage)
+	err = fio.IsValid()
+	if err != nil {
+		metrics.ConsumerFailed.WithLabelValues(metrics.ReasonValidation).Inc()
+		span.SetStatus(codes.Error, metrics.ReasonValidation)
+		errorBytes, marshalErr := json.Marshal(failedMessage(err, fioMessage))
+		if marshalErr != nil {
+			logger.FromContext(ctx).Error("Failed to marshal error message:", marshalErr)
+			return
+		}
+		// Отправляем сообщение об ошибке в очередь FIO_FAILED
+		if pubErr := f.kafkaService.PublishToTopic(ctx, "FIO_FAILED", errorBytes); pubErr != nil {
+			logger.FromContext(ctx).Error("Failed to publish message to FIO_FAILED queue:", pubErr)
+		}
+		return
+	}
+
+	// Обогащение и сохранение в БД, в журнале аудита автором записывается исходное сообщение
+	ctx = repo.WithActor(ctx, repo.Actor{
+		Name:   fmt.Sprintf("%s/%d@%d", msg.Topic, msg.Partition, msg.Offset),
+		Source: repo.SourceKafka,
+	})
+	if err := f.enrichAndSave(ctx, fio, fioMessage); err != nil {
+		logger.FromContext(ctx).Error(err)
+		reason := metrics.ReasonSave
+		if errors.Is(err, errEnrich) {
+			reason = metrics.ReasonEnrich
 		}
+		metrics.ConsumerFailed.WithLabelValues(reason).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, reason)
+		return
+	}
+	metrics.ConsumerProcessed.Inc()
+}
+
+// failedMessage - сообщение для очереди FIO_FAILED. Список нарушений по полям добавляется, только если
+// err - ValidationErrors, остальные ошибки сериализуются в пустой объект.
+func failedMessage(err error, original FIO) map[string]interface{} {
+	msg := map[string]interface{}{
+		"error":            err.Error(),
+		"original_message": original,
 	}
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		msg["errors"] = validationErrs
+	}
+	return msg
+}
+
+// errEnrich - ошибка обогащения в enrichAndSave, остальные ошибки относятся к сохранению
+var errEnrich = errors.New("failed to enrich the FIO data")
+
+// enrichAndSave обогащает нормализованное валидное ФИО и сохраняет пользователя вместе с исходным raw,
+// общий путь для Kafka и импорта из файлов
+func (f *FIOService) enrichAndSave(ctx context.Context, fio, raw FIO) error {
+	enrichedData, err := f.enrichFIOData(ctx, fio)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errEnrich, err)
+	}
+
+	user := convertToUser(enrichedData)
+	user.RawInput = rawFIO(raw)
+	user.Latin = f.latinFIO(fio)
+	if err := f.userRepo.Save(ctx, user); err != nil {
+		return fmt.Errorf("failed to save user to the database: %w", err)
+	}
+	f.invalidateUsers(ctx)
+	return nil
 }
 
 // GetUsers получает пользователей по заданным параметрам с пагинацией
@@ -123,88 +237,153 @@ func (f *FIOService) ProcessMessages() {
 func (f *FIOService) GetUsers(c echo.Context) error {
 	pageStr := c.QueryParam("page")
 	sizeStr := c.QueryParam("size")
-	filter := c.QueryParam("filter")
+
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		return err
+	}
 
 	// Преобразование из строки в int
 	page, err := strconv.Atoi(pageStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, "Invalid page parameter")
+		return InvalidParameter("page")
 	}
 
 	size, err := strconv.Atoi(sizeStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, "Invalid size parameter")
+		return InvalidParameter("size")
 	}
 
-	// Составляем ключ для кеширования
-	cacheKey := fmt.Sprintf("users:p=%d:s=%d:f=%s", page, size, filter)
-	cachedData, err := f.RedisClient.Get(c.Request().Context(), cacheKey).Result()
+	// Составляем ключ для кеширования, поколение в ключе меняется при каждом изменении пользователей
+	ctx := c.Request().Context()
+	gen, cacheable := f.usersGeneration(ctx)
+	cacheKey := usersListCacheKey(gen, page, size, filter.Name, filter.IncludeDeleted)
 
-	if err == nil {
-		// Если в кеше есть данные, десериализуем их и возвращаем
-		var users []model.User
-		err = json.Unmarshal([]byte(cachedData), &users)
-		if err == nil {
-			return c.JSON(http.StatusOK, users)
+	// Если за время запроса поколение сменилось, страница ляжет под старым ключом и читаться уже не будет
+	data, err := f.loadCached(ctx, cacheKey, cacheable, func(ctx context.Context) ([]byte, error) {
+		users, err := f.userRepo.GetUsers(ctx, page, size, filter)
+		if err != nil {
+			return nil, err
 		}
+		return json.Marshal(users)
+	})
+	if err != nil {
+		return Internal("Failed to fetch users", err)
+	}
+
+	return c.JSONBlob(http.StatusOK, data)
+}
+
+// GetUser возвращает пользователя по id, удаленного - только с include_deleted=true
+func (f *FIOService) GetUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return InvalidParameter("id")
 	}
 
-	users, err := f.userRepo.GetUsers(page, size, filter)
+	filter, err := parseUserFilter(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch users",
-		})
+		return err
 	}
 
-	// Сериализуем новые данные и сохраняем их в кеш
-	data, _ := json.Marshal(users)
-	f.RedisClient.Set(c.Request().Context(), cacheKey, data, CacheExpiration)
+	data, err := f.loadCached(c.Request().Context(), userCacheKey(id), true, func(ctx context.Context) ([]byte, error) {
+		user, err := f.userRepo.GetUser(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(user)
+	})
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return NotFound("User not found")
+	}
+	if err != nil {
+		return Internal("Failed to fetch user", err)
+	}
 
-	return c.JSON(http.StatusOK, users)
+	var user model.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return Internal("Failed to fetch user", err)
+	}
+	if user.DeletedAt != nil && !filter.IncludeDeleted {
+		return NotFound("User not found")
+	}
+	return c.JSON(http.StatusOK, user)
 }
 
 // AddUser добавляет пользователя, обязательные параметры name и surname, возвращает объект добавленного пользователя
 //
 //go:generate mockgen -destination=./mocks/user_repo_mock.go -package=mocks user-service/repo UserRepo
+//go:generate mockgen -destination=./mocks/import_repo_mock.go -package=mocks user-service/repo ImportRepo
+//go:generate mockgen -destination=./mocks/apikey_repo_mock.go -package=mocks user-service/repo APIKeyRepo
+//go:generate mockgen -destination=./mocks/outbox_repo_mock.go -package=mocks user-service/repo OutboxRepo
 func (f *FIOService) AddUser(c echo.Context) error {
 	user := model.User{}
-	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Failed to parse request body",
-		})
-	}
-	// Проверка на наличие name и username
-	if user.Name == "" || user.Surname == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Both name and username are required",
-		})
+	if err := f.bindUser(c, &user); err != nil {
+		return err
 	}
 
-	id, err := f.userRepo.AddUser(user)
+	id, err := f.userRepo.AddUser(requestContext(c), user)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to add user",
-		})
+		return Internal("Failed to add user", err)
 	}
 	user.ID = id
+	f.invalidateUsers(c.Request().Context())
 	return c.JSON(http.StatusCreated, user)
 }
 
-// DeleteUser удаляет пользователя по заданному id
+// DeleteUser мягко удаляет пользователя по заданному id, его можно восстановить до истечения срока хранения
 func (f *FIOService) DeleteUser(c echo.Context) error {
 	idStr := c.Param("id")
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, "Invalid size parameter")
+		return InvalidParameter("id")
+	}
+
+	err = f.userRepo.DeleteUser(requestContext(c), id)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return NotFound("User not found")
+	}
+	if err != nil {
+		return Internal("Failed to delete user", err)
+	}
+	f.invalidateUsers(c.Request().Context(), id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RestoreUser восстанавливает мягко удаленного пользователя
+func (f *FIOService) RestoreUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return InvalidParameter("id")
+	}
+
+	err = f.userRepo.RestoreUser(requestContext(c), id)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return NotFound("Deleted user not found")
+	}
+	if err != nil {
+		return Internal("Failed to restore user", err)
+	}
+	f.invalidateUsers(c.Request().Context(), id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PurgeUser окончательно удаляет пользователя без возможности восстановления
+func (f *FIOService) PurgeUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return InvalidParameter("id")
 	}
 
-	err = f.userRepo.DeleteUser(id)
+	err = f.userRepo.PurgeUser(requestContext(c), id)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return NotFound("User not found")
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to delete user",
-		})
+		return Internal("Failed to purge user", err)
 	}
+	f.invalidateUsers(c.Request().Context(), id)
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -214,27 +393,42 @@ func (f *FIOService) UpdateUser(c echo.Context) error {
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, "Invalid ID parameter")
+		return InvalidParameter("id")
 	}
 
 	user := model.User{}
-	if err := c.Bind(&user); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Failed to parse request body",
-		})
+	if err := f.bindUser(c, &user); err != nil {
+		return err
 	}
 
 	user.ID = id
 
-	err = f.userRepo.UpdateUser(user)
+	err = f.userRepo.UpdateUser(requestContext(c), user)
+	if errors.Is(err, repo.ErrUserNotFound) {
+		return NotFound("User not found")
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update user",
-		})
+		return Internal("Failed to update user", err)
 	}
+	f.invalidateUsers(c.Request().Context(), id)
 	return c.JSON(http.StatusOK, user)
 }
 
+// parseUserFilter читает из запроса параметры фильтрации, общие для списка и выгрузки пользователей
+func parseUserFilter(c echo.Context) (repo.UserFilter, error) {
+	filter := repo.UserFilter{Name: c.QueryParam("filter")}
+
+	// По умолчанию удаленные пользователи в выборку не попадают
+	if includeStr := c.QueryParam("include_deleted"); includeStr != "" {
+		includeDeleted, err := strconv.ParseBool(includeStr)
+		if err != nil {
+			return repo.UserFilter{}, InvalidParameter("include_deleted")
+		}
+		filter.IncludeDeleted = includeDeleted
+	}
+	return filter, nil
+}
+
 func convertToUser(data EnrichedFIO) model.User {
 	// Простой пример: взять страну с наибольшей вероятностью.
 	// На практике можно добавить дополнительную логику или обработку ошибок.
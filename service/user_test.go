@@ -2,14 +2,22 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"user-service/api_clients/model"
+	"user-service/pkg/cache"
+	"user-service/pkg/kafka"
+	"user-service/pkg/logger"
+	"user-service/repo"
 	"user-service/service/mocks"
 
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
+	kafkago "github.com/segmentio/kafka-go"
 )
 
 func TestAddUser(t *testing.T) {
@@ -25,7 +33,7 @@ func TestAddUser(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		_ = f.AddUser(c) // Вызываем метод и игнорируем ошибку, так как она будет возвращена в HTTP ответе
+		handle(c, f.AddUser) // ошибка обработчика превращается в HTTP-ответ так же, как в echo
 
 		if got, want := rec.Code, http.StatusBadRequest; got != want {
 			t.Errorf("got status %d, wanted %d", got, want)
@@ -38,22 +46,65 @@ func TestAddUser(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		_ = f.AddUser(c)
+		handle(c, f.AddUser)
 
 		if got, want := rec.Code, http.StatusBadRequest; got != want {
 			t.Errorf("got status %d, wanted %d", got, want)
 		}
 	})
 
+	t.Run("All invalid fields are reported", func(t *testing.T) {
+		userJSON := `{"name": "John", "surname": "Smith", "age": -5, "gender": "banana", "nationality": "XX"}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer([]byte(userJSON)))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.AddUser)
+
+		if got, want := rec.Code, http.StatusBadRequest; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+		for _, field := range []string{`"field":"age"`, `"field":"gender"`, `"code":"unknown_country"`} {
+			if !strings.Contains(rec.Body.String(), field) {
+				t.Errorf("body %s does not contain %s", rec.Body.String(), field)
+			}
+		}
+	})
+
+	t.Run("Name is normalized before saving", func(t *testing.T) {
+		mockUserRepo.EXPECT().AddUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user model.User) (int, error) {
+			if user.Name != "Ivan" || user.Surname != "Saltykov-Shchedrin" || user.Nationality != "RU" {
+				t.Errorf("got unnormalized user %+v", user)
+			}
+			if got, want := string(user.RawInput), `{"name":"  IVAN ","surname":"saltykov - shchedrin"}`; got != want {
+				t.Errorf("got raw input %s, wanted %s", got, want)
+			}
+			return 1, nil
+		})
+
+		userJSON := `{"name": "  IVAN ", "surname": "saltykov - shchedrin", "nationality": "ru"}`
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer([]byte(userJSON)))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handle(c, f.AddUser)
+
+		if got, want := rec.Code, http.StatusCreated; got != want {
+			t.Errorf("got status %d, wanted %d", got, want)
+		}
+	})
+
 	t.Run("Failed to add user", func(t *testing.T) {
-		mockUserRepo.EXPECT().AddUser(gomock.Any()).Return(0, errors.New("DB error"))
+		mockUserRepo.EXPECT().AddUser(gomock.Any(), gomock.Any()).Return(0, errors.New("DB error"))
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer([]byte(`{"Name": "John", "Surname": "Smith"}`)))
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		_ = f.AddUser(c)
+		handle(c, f.AddUser)
 
 		if got, want := rec.Code, http.StatusInternalServerError; got != want {
 			t.Errorf("got status %d, wanted %d", got, want)
@@ -61,17 +112,213 @@ func TestAddUser(t *testing.T) {
 	})
 
 	t.Run("User added successfully", func(t *testing.T) {
-		mockUserRepo.EXPECT().AddUser(gomock.Any()).Return(1, nil)
+		mockUserRepo.EXPECT().AddUser(gomock.Any(), gomock.Any()).Return(1, nil)
 
 		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer([]byte(`{"Name": "Franz", "Surname": "Kafka"}`)))
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		_ = f.AddUser(c)
+		handle(c, f.AddUser)
 
 		if got, want := rec.Code, http.StatusCreated; got != want {
 			t.Errorf("got status %d, wanted %d", got, want)
 		}
 	})
 }
+
+func TestDeleteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	tests := []struct {
+		name     string
+		id       string
+		repoErr  error
+		callRepo bool
+		expected int
+	}{
+		{name: "Invalid id", id: "abc", expected: http.StatusBadRequest},
+		{name: "User not found", id: "1", repoErr: repo.ErrUserNotFound, callRepo: true, expected: http.StatusNotFound},
+		{name: "Failed to delete user", id: "1", repoErr: errors.New("DB error"), callRepo: true, expected: http.StatusInternalServerError},
+		{name: "User deleted successfully", id: "1", callRepo: true, expected: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.callRepo {
+				mockUserRepo.EXPECT().DeleteUser(gomock.Any(), 1).Return(test.repoErr)
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(test.id)
+
+			handle(c, f.DeleteUser)
+
+			if got, want := rec.Code, test.expected; got != want {
+				t.Errorf("got status %d, wanted %d", got, want)
+			}
+		})
+	}
+}
+
+func TestUpdateUserStoresValidatedFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	// поля, которые проверяются при PUT, должны доходить до хранилища, а не отбрасываться молча
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user model.User) error {
+		if user.ID != 1 || user.Age != 30 || user.Gender != "male" || user.Nationality != "RU" {
+			t.Errorf("unexpected user passed to repo: %+v", user)
+		}
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/", bytes.NewBuffer([]byte(
+		`{"name": "Ivan", "surname": "Petrov", "age": 30, "gender": "male", "nationality": "RU"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	handle(c, f.UpdateUser)
+
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("got status %d, wanted %d: %s", got, want, rec.Body.String())
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+	f := &FIOService{userRepo: mockUserRepo}
+
+	tests := []struct {
+		name     string
+		repoErr  error
+		expected int
+	}{
+		{name: "Deleted user not found", repoErr: repo.ErrUserNotFound, expected: http.StatusNotFound},
+		{name: "User restored successfully", expected: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockUserRepo.EXPECT().RestoreUser(gomock.Any(), 7).Return(test.repoErr)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("7")
+
+			handle(c, f.RestoreUser)
+
+			if got, want := rec.Code, test.expected; got != want {
+				t.Errorf("got status %d, wanted %d", got, want)
+			}
+		})
+	}
+}
+
+func TestGetUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	e := echo.New()
+
+	// сервис должен работать и без кэша, и с отключенным кэшем
+	services := map[string]*FIOService{
+		"without cache": {userRepo: mockUserRepo},
+		"noop cache":    {userRepo: mockUserRepo, store: cache.Noop{}, cache: cache.NewReadThrough(cache.Noop{}, cache.Noop{})},
+	}
+
+	for name, f := range services {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Invalid page parameter", func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/users?page=abc", nil)
+				rec := httptest.NewRecorder()
+
+				handle(e.NewContext(req, rec), f.GetUsers)
+
+				if got, want := rec.Code, http.StatusBadRequest; got != want {
+					t.Errorf("got status %d, wanted %d", got, want)
+				}
+			})
+
+			t.Run("Failed to fetch users", func(t *testing.T) {
+				mockUserRepo.EXPECT().GetUsers(gomock.Any(), 1, 10, gomock.Any()).Return(nil, errors.New("DB error"))
+
+				req := httptest.NewRequest(http.MethodGet, "/users?page=1&size=10", nil)
+				rec := httptest.NewRecorder()
+
+				handle(e.NewContext(req, rec), f.GetUsers)
+
+				if got, want := rec.Code, http.StatusInternalServerError; got != want {
+					t.Errorf("got status %d, wanted %d", got, want)
+				}
+			})
+
+			t.Run("Users fetched from repository every time", func(t *testing.T) {
+				mockUserRepo.EXPECT().GetUsers(gomock.Any(), 1, 10, gomock.Any()).Return([]model.User{{ID: 1, Name: "Franz"}}, nil).Times(2)
+
+				for i := 0; i < 2; i++ {
+					req := httptest.NewRequest(http.MethodGet, "/users?page=1&size=10", nil)
+					rec := httptest.NewRecorder()
+
+					handle(e.NewContext(req, rec), f.GetUsers)
+
+					if got, want := rec.Code, http.StatusOK; got != want {
+						t.Errorf("got status %d, wanted %d", got, want)
+					}
+					if !strings.Contains(rec.Body.String(), `"Franz"`) {
+						t.Errorf("unexpected body %s", rec.Body.String())
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestMessageRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"incoming kept", "req-42", true},
+		{"missing generated", "", false},
+		{"unsafe replaced", "evil\n\"level\":\"error", false},
+		{"too long replaced", strings.Repeat("a", 200), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []kafkago.Header
+			if tt.incoming != "" {
+				headers = append(headers, kafkago.Header{Key: logger.HeaderRequestID, Value: []byte(tt.incoming)})
+			}
+
+			got := messageRequestID(kafka.HeaderCarrier{Headers: &headers})
+
+			if !logger.ValidRequestID(got) || (got == tt.incoming) != tt.keep {
+				t.Errorf("got id %q for incoming %q", got, tt.incoming)
+			}
+		})
+	}
+}
@@ -1,16 +1,155 @@
 package service
 
-import "errors"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"user-service/api_clients/model"
 
-func (f *FIO) IsValid() error {
-	if f.Name == "" {
-		return errors.New("name is required")
+	"github.com/labstack/echo/v4"
+)
+
+// Коды нарушений правил валидации, возвращаются клиенту в поле code ошибки по полю
+const (
+	RuleRequired     = "required"
+	RuleTooLong      = "too_long"
+	RuleCharacters   = "invalid_characters"
+	RuleOutOfRange   = "out_of_range"
+	RuleInvalidValue = "invalid_value"
+	RuleCountry      = "unknown_country"
+)
+
+// Ограничения на данные пользователя
+const (
+	MaxNameLength = 100
+	MinAge        = 0
+	MaxAge        = 150
+)
+
+// Genders - допустимые значения пола, пустое значение означает, что пол неизвестен
+var Genders = []string{"male", "female"}
+
+// ValidationErrors - все нарушения, найденные при проверке объекта
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fieldErr := range v {
+		messages[i] = fieldErr.Field + " " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validator собирает нарушения по полям, prefix добавляется к пути каждого поля
+type validator struct {
+	prefix string
+	errs   ValidationErrors
+}
+
+func (v *validator) add(field, code, message string) {
+	v.errs = append(v.errs, FieldError{Field: v.prefix + field, Code: code, Message: message})
+}
+
+// name проверяет часть ФИО: буквы любого алфавита, между ними - дефисы, апострофы и пробелы
+func (v *validator) name(field, value string, required bool) {
+	switch {
+	case value == "":
+		if required {
+			v.add(field, RuleRequired, "is required")
+		}
+	case utf8.RuneCountInString(value) > MaxNameLength:
+		v.add(field, RuleTooLong, fmt.Sprintf("must be at most %d characters", MaxNameLength))
+	case !isName(value):
+		v.add(field, RuleCharacters, "must contain only letters, hyphens and apostrophes")
+	}
+}
+
+func (v *validator) age(field string, value int) {
+	if value < MinAge || value > MaxAge {
+		v.add(field, RuleOutOfRange, fmt.Sprintf("must be between %d and %d", MinAge, MaxAge))
+	}
+}
+
+func (v *validator) gender(field, value string) {
+	if value == "" {
+		return
+	}
+	for _, gender := range Genders {
+		if value == gender {
+			return
+		}
+	}
+	v.add(field, RuleInvalidValue, "must be one of "+strings.Join(Genders, ", "))
+}
+
+func (v *validator) nationality(field, value string) {
+	if value != "" && !IsCountryCode(value) {
+		v.add(field, RuleCountry, "must be an ISO 3166-1 alpha-2 country code")
 	}
-	if f.Surname == "" {
-		return errors.New("surname is required")
+}
+
+// result возвращает nil, если нарушений нет
+func (v *validator) result() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// isName проверяет, что строка начинается и заканчивается буквой, а разделители не идут подряд
+func isName(value string) bool {
+	prevSeparator := true
+	for _, r := range value {
+		switch {
+		case unicode.IsLetter(r):
+			prevSeparator = false
+		case unicode.Is(unicode.Mn, r):
+			// диакритические знаки допустимы только после буквы
+			if prevSeparator {
+				return false
+			}
+		case r == '-' || r == '\'' || r == '’' || r == ' ':
+			if prevSeparator {
+				return false
+			}
+			prevSeparator = true
+		default:
+			return false
+		}
 	}
-	// остальная валидация во многом зависит от бизнес-логики и требований к данным,
-	//можно проверить длину параметров, что они содержат только буквы и т.д.
+	return !prevSeparator
+}
+
+// IsValid проверяет ФИО из Kafka и файлов импорта, возвращает ValidationErrors со всеми нарушениями
+func (f *FIO) IsValid() error {
+	v := &validator{}
+	v.name("name", f.Name, true)
+	v.name("surname", f.Surname, true)
+	v.name("patronymic", f.Patronymic, false)
+	return v.result()
+}
 
+// validateUser проверяет пользователя из REST API, prefix - путь к объекту в теле запроса
+func validateUser(user model.User, prefix string) error {
+	v := &validator{prefix: prefix}
+	v.name("name", user.Name, true)
+	v.name("surname", user.Surname, true)
+	v.name("patronymic", user.Patronymic, false)
+	v.age("age", user.Age)
+	v.gender("gender", user.Gender)
+	v.nationality("nationality", user.Nationality)
+	return v.result()
+}
+
+// bindUser разбирает тело запроса, нормализует и проверяет пользователя, ошибки возвращаются в формате API
+func (f *FIOService) bindUser(c echo.Context, user *model.User) error {
+	if err := c.Bind(user); err != nil {
+		return InvalidBody(err)
+	}
+	f.normalizeUser(user)
+	if err := validateUser(*user, ""); err != nil {
+		return Validation("Validation failed", err.(ValidationErrors)...)
+	}
 	return nil
 }
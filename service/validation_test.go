@@ -2,7 +2,9 @@ package service
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"user-service/api_clients/model"
 )
 
 func TestIsValid(t *testing.T) {
@@ -12,7 +14,7 @@ func TestIsValid(t *testing.T) {
 	}{
 		{
 			fio:      FIO{Name: "", Surname: ""},
-			expected: errors.New("name is required"),
+			expected: errors.New("name is required; surname is required"),
 		},
 		{
 			fio:      FIO{Name: "John", Surname: ""},
@@ -22,6 +24,14 @@ func TestIsValid(t *testing.T) {
 			fio:      FIO{Name: "John", Surname: "Smith"},
 			expected: nil,
 		},
+		{
+			fio:      FIO{Name: "Анна-Мария", Surname: "O’Connor", Patronymic: "d'Artagnan"},
+			expected: nil,
+		},
+		{
+			fio:      FIO{Name: "John2", Surname: strings.Repeat("a", MaxNameLength+1), Patronymic: "-Ivanovich"},
+			expected: errors.New("name must contain only letters, hyphens and apostrophes; surname must be at most 100 characters; patronymic must contain only letters, hyphens and apostrophes"),
+		},
 	}
 
 	for _, test := range tests {
@@ -40,3 +50,94 @@ func TestIsValid(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateUser(t *testing.T) {
+	valid := model.User{Name: "Ivan", Surname: "Ivanov", Age: 42, Gender: "male", Nationality: "RU"}
+
+	tests := []struct {
+		name  string
+		user  func(u *model.User)
+		codes map[string]string
+	}{
+		{
+			name: "Valid user",
+			user: func(u *model.User) {},
+		},
+		{
+			name: "Unknown age, gender and nationality",
+			user: func(u *model.User) { u.Age, u.Gender, u.Nationality = 0, "", "" },
+		},
+		{
+			name: "All violations are reported",
+			user: func(u *model.User) {
+				u.Name = ""
+				u.Surname = "Ivanov "
+				u.Age = -1
+				u.Gender = "banana"
+				u.Nationality = "XX"
+			},
+			codes: map[string]string{
+				"user.name":        RuleRequired,
+				"user.surname":     RuleCharacters,
+				"user.age":         RuleOutOfRange,
+				"user.gender":      RuleInvalidValue,
+				"user.nationality": RuleCountry,
+			},
+		},
+		{
+			name:  "Lowercase country code",
+			user:  func(u *model.User) { u.Nationality = "ru" },
+			codes: map[string]string{"user.nationality": RuleCountry},
+		},
+		{
+			name:  "Age above limit",
+			user:  func(u *model.User) { u.Age = MaxAge + 1 },
+			codes: map[string]string{"user.age": RuleOutOfRange},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := valid
+			test.user(&user)
+
+			err := validateUser(user, "user.")
+			if len(test.codes) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, but got %v", err)
+				}
+				return
+			}
+
+			var violations ValidationErrors
+			if !errors.As(err, &violations) {
+				t.Fatalf("expected ValidationErrors, but got %v", err)
+			}
+			if len(violations) != len(test.codes) {
+				t.Errorf("got %d violations, wanted %d: %v", len(violations), len(test.codes), err)
+			}
+			for _, violation := range violations {
+				if code := test.codes[violation.Field]; code != violation.Code {
+					t.Errorf("got code %q for %s, wanted %q", violation.Code, violation.Field, code)
+				}
+			}
+		})
+	}
+}
+
+func TestFailedMessage(t *testing.T) {
+	fio := FIO{Name: "John"}
+
+	msg := failedMessage(fio.IsValid(), fio)
+	if errs, ok := msg["errors"].(ValidationErrors); !ok || len(errs) == 0 {
+		t.Errorf("expected validation errors, got %#v", msg["errors"])
+	}
+
+	msg = failedMessage(errors.New("boom"), fio)
+	if _, ok := msg["errors"]; ok {
+		t.Errorf("errors must be omitted for non-validation error, got %#v", msg["errors"])
+	}
+	if msg["error"] != "boom" {
+		t.Errorf("got error %v, wanted boom", msg["error"])
+	}
+}